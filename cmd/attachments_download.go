@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/open-cli-collective/gmail-ro/internal/downloads"
 	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	ziputil "github.com/open-cli-collective/gmail-ro/internal/zip"
 	"github.com/spf13/cobra"
@@ -16,6 +23,15 @@ var (
 	downloadDir      string
 	downloadExtract  bool
 	downloadAll      bool
+	downloadQuery    string
+	downloadMax      int64
+	downloadFilters  attachmentFilterFlags
+	downloadLayout   string
+	downloadWorkers  int
+	downloadConflict string
+	downloadDedupe   string
+	downloadResume   bool
+	downloadNoResume bool
 )
 
 func init() {
@@ -27,104 +43,523 @@ func init() {
 	downloadAttachmentsCmd.Flags().BoolVarP(&downloadExtract, "extract", "e", false,
 		"Extract zip files after download")
 	downloadAttachmentsCmd.Flags().BoolVarP(&downloadAll, "all", "a", false,
-		"Download all attachments (required if no --filename specified)")
+		"Download all attachments (required if no filter such as --filename, --match or --mime is given)")
+	downloadAttachmentsCmd.Flags().StringVarP(&downloadQuery, "query", "q", "",
+		"Download from every message matching this Gmail query instead of a single message")
+	downloadAttachmentsCmd.Flags().Int64VarP(&downloadMax, "max", "m", 0,
+		"Maximum number of messages to search with --query (0 for no limit)")
+	downloadAttachmentsCmd.Flags().StringVar(&downloadLayout, "layout", defaultLayout,
+		"Template for file paths under the output directory, e.g. {{.Date}}/{{.From}}/{{.Filename}}")
+	downloadAttachmentsCmd.Flags().IntVar(&downloadWorkers, "concurrency", defaultConcurrency,
+		"Number of messages and attachments to fetch at the same time")
+	downloadAttachmentsCmd.Flags().StringVar(&downloadConflict, "on-conflict", "rename",
+		"What to do when a file already exists: "+strings.Join(conflictModes, "|"))
+	downloadAttachmentsCmd.Flags().StringVar(&downloadDedupe, "dedupe", "",
+		"Deduplicate identical files: link (hard link to the first copy) or skip")
+	downloadAttachmentsCmd.Flags().Lookup("dedupe").NoOptDefVal = "link"
+	downloadAttachmentsCmd.Flags().BoolVar(&downloadResume, "resume", false,
+		"Record downloads in the manifest and skip completed ones (always on with --query)")
+	downloadAttachmentsCmd.Flags().BoolVar(&downloadNoResume, "no-resume", false,
+		"With --query, download attachments again even if the manifest records them as complete (implied by --on-conflict overwrite)")
+	downloadFilters.register(downloadAttachmentsCmd)
 }
 
 var downloadAttachmentsCmd = &cobra.Command{
-	Use:   "download <message-id>",
-	Short: "Download attachments from a message",
+	Use:   "download [message-id]",
+	Short: "Download attachments from messages",
 	Long: `Download attachments from a Gmail message to local disk.
 
-By default, requires --filename to specify which attachment to download,
-or --all to download all attachments.
+By default, requires --filename or another filter to select which
+attachments to download, or --all to download all attachments. Filters are
+evaluated against the attachment metadata before anything is downloaded:
+--match takes a filename glob, --regex a regular expression, --mime a MIME
+type or glob such as 'image/*', --min-size and --max-size a size like 100K,
+and --exclude-inline skips embedded images. All given filters must match.
+
+With --query instead of a message ID, attachments are downloaded from every
+message matching the Gmail query. Messages and attachments are fetched
+concurrently (see --concurrency).
+
+--layout sets where each file goes under the output directory using a Go
+template. Available fields: {{.Date}} (YYYY-MM-DD), {{.Year}}, {{.Month}},
+{{.Day}}, {{.From}} (sender email), {{.FromName}}, {{.Subject}},
+{{.MessageID}}, {{.ThreadID}}, {{.Filename}} and {{.PartID}}. Dates use
+the --tz timezone.
+
+Attachment filenames and layout values are sanitized before use: path
+separators and characters Windows does not allow become '_', control
+characters are removed, reserved device names such as CON are prefixed and
+overly long names are shortened, so a file can never be written outside the
+output directory.
+
+--on-conflict decides what happens when a file already exists, or when two
+attachments of the same run would be saved under the same name: rename
+(the default) adds a numbered suffix such as image001-1.png, skip leaves
+the existing file alone, overwrite replaces it, and fail stops before
+downloading anything. overwrite only replaces files from earlier runs;
+attachments of the same run that share a name are still numbered.
+
+Downloads with --query, or with --resume, are recorded in a manifest,
+.gmro/manifest.json in the output directory, with their message, part and
+attachment IDs, size, SHA-256, path and status. Running the same command
+again resumes: attachments the manifest records as complete (and whose
+files are still in place) are skipped, and failed or missing ones are
+downloaded again. Use --no-resume, or --on-conflict overwrite, to download
+everything again. Downloads from a single message without --resume do not
+use the manifest.
+
+The manifest identifies attachments by message and part ID only. A rerun
+with a different --layout therefore reports files at the paths the earlier
+run saved them to and does not save them again under the new layout; use
+--no-resume for that. A different --output has its own manifest.
+
+--dedupe identifies files by their SHA-256 and keeps a content-addressed
+store in the .gmro directory, so the same logo or PDF arriving on many
+messages is stored once. The first copy of each file is hard linked into
+the store and --dedupe (or --dedupe=link) saves duplicates as hard links to
+it. Linked files are read-only: they share their content, so editing one
+would change them all. Where hard links are not supported, the store keeps
+its own copy and duplicates are kept as full copies with a warning.
+--dedupe=skip does not save duplicates at all.
 
 Zip files can be automatically extracted with --extract flag.
 
+Attachments are streamed to a temporary file next to the destination and
+renamed into place once the download is complete and its size matches the
+size Gmail reports, so a failed download never leaves a partial file. A
+progress bar is shown while downloading when stderr is a terminal and
+files are downloaded one at a time.
+
 Examples:
   gmro attachments download 18abc123def456 --filename report.pdf
   gmro attachments download 18abc123def456 --all
   gmro attachments download 18abc123def456 --all --output ~/Downloads
-  gmro attachments download 18abc123def456 --filename archive.zip --extract`,
-	Args: cobra.ExactArgs(1),
+  gmro attachments download 18abc123def456 --filename archive.zip --extract
+  gmro attachments download 18abc123def456 --match '*.pdf' --match '*.xlsx'
+  gmro attachments download 18abc123def456 --mime 'image/*' --exclude-inline --min-size 50K
+  gmro attachments download --query "has:attachment" --all --dedupe --layout "{{.From}}/{{.Filename}}"
+  gmro attachments download --query "has:attachment from:billing@example.com" --mime application/pdf
+  gmro attachments download --query "label:invoices newer_than:7d" --all --layout "{{.Date}}/{{.From}}/{{.Filename}}"`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if downloadFilename == "" && !downloadAll {
-			return fmt.Errorf("must specify --filename or --all")
+		if (len(args) == 0) == (downloadQuery == "") {
+			return fmt.Errorf("specify either a message ID or --query")
 		}
-
-		client, err := newGmailClient()
+		filter, err := downloadFilters.compile(downloadFilename)
 		if err != nil {
 			return err
 		}
-
-		messageID := args[0]
-		attachments, err := client.GetAttachments(messageID)
+		if !filter.selects() && !downloadAll {
+			return fmt.Errorf("must specify --all or a filter such as --filename, --match or --mime")
+		}
+		if err := validateConcurrency(downloadWorkers); err != nil {
+			return err
+		}
+		if err := validateConflictMode(downloadConflict); err != nil {
+			return err
+		}
+		if downloadResume && downloadNoResume {
+			return fmt.Errorf("--resume and --no-resume cannot be used together")
+		}
+		if downloadDedupe != "" && downloadDedupe != "link" && downloadDedupe != "skip" {
+			return fmt.Errorf("invalid --dedupe %q: must be link or skip", downloadDedupe)
+		}
+		layout, err := parseLayout(downloadLayout)
 		if err != nil {
 			return err
 		}
 
-		if len(attachments) == 0 {
-			fmt.Println("No attachments found for message.")
-			return nil
+		client, err := newGmailClient()
+		if err != nil {
+			return err
 		}
 
-		// Filter by filename if specified
-		var toDownload []*gmail.Attachment
-		for _, att := range attachments {
-			if downloadFilename == "" || att.Filename == downloadFilename {
-				toDownload = append(toDownload, att)
+		// Bulk downloads are resumable; a single message only with --resume
+		var manifest *downloads.Manifest
+		if downloadQuery != "" || downloadResume {
+			manifest, err = downloads.LoadManifest(downloads.ManifestPath(downloadDir))
+			if err != nil {
+				return err
 			}
 		}
+		var store *downloads.Store
+		if downloadDedupe != "" {
+			store = downloads.NewStore(downloadDir)
+		}
 
-		if len(toDownload) == 0 {
-			return fmt.Errorf("attachment not found: %s", downloadFilename)
+		ids := args
+		if downloadQuery != "" {
+			ids, err = client.ListMessageIDs(downloadQuery, downloadMax)
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				fmt.Println("No messages found.")
+				return nil
+			}
 		}
 
-		// Create output directory if needed
-		if err := os.MkdirAll(downloadDir, 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %w", err)
+		results := fetchAll(ids, downloadWorkers, func(id string) (*gmail.Message, error) {
+			return client.GetMessage(id, true)
+		})
+		if len(ids) == 1 && results[0].Err != nil {
+			return results[0].Err
 		}
+		failed := reportFetchErrors(os.Stderr, results)
 
-		// Download each attachment
-		for _, att := range toDownload {
-			data, err := downloadAttachment(client, messageID, att)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error downloading %s: %v\n", att.Filename, err)
-				continue
-			}
+		// Overwriting is a request to download again, so it disables resume
+		resume := manifest != nil && !downloadNoResume && downloadConflict != "overwrite"
 
-			outputPath := filepath.Join(downloadDir, att.Filename)
-			if err := saveAttachment(outputPath, data); err != nil {
-				fmt.Fprintf(os.Stderr, "Error saving %s: %v\n", att.Filename, err)
+		var jobs []downloadJob
+		found, skipped, resumed := 0, 0, 0
+		claimed := make(map[string]bool)
+		for _, r := range results {
+			if r.Err != nil {
 				continue
 			}
+			found += len(r.Value.Attachments)
+			for _, att := range filter.apply(r.Value.Attachments) {
+				if resume {
+					if entry, ok := manifest.Get(r.Value.ID, att.PartID); ok && isDownloaded(entry) {
+						fmt.Printf("Already downloaded: %s\n", describeEntry(entry))
+						resumed++
+						continue
+					}
+				}
 
-			fmt.Printf("Downloaded: %s (%s)\n", outputPath, formatSize(int64(len(data))))
-
-			// Extract if zip and --extract flag
-			if downloadExtract && isZipFile(att.Filename, att.MimeType) {
-				extractDir := filepath.Join(downloadDir,
-					strings.TrimSuffix(att.Filename, filepath.Ext(att.Filename)))
-				if err := ziputil.Extract(outputPath, extractDir, ziputil.DefaultOptions()); err != nil {
-					fmt.Fprintf(os.Stderr, "Error extracting %s: %v\n", att.Filename, err)
-				} else {
-					fmt.Printf("Extracted to: %s\n", extractDir)
+				rel, err := renderLayout(layout, newLayoutData(r.Value, att))
+				if err != nil {
+					return err
 				}
+				path, err := resolveConflict(filepath.Join(downloadDir, rel), downloadConflict, claimed)
+				if errors.Is(err, errSkipExisting) {
+					fmt.Printf("Skipped: %s (already exists)\n", filepath.Join(downloadDir, rel))
+					skipped++
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				claimed[path] = true
+				jobs = append(jobs, downloadJob{messageID: r.Value.ID, att: att, path: path})
+			}
+		}
+
+		if len(jobs) == 0 {
+			switch {
+			case skipped > 0 || resumed > 0:
+				// Every match already exists and was reported above
+			case downloadQuery != "":
+				fmt.Println("No matching attachments found.")
+			case found == 0:
+				fmt.Println("No attachments found for message.")
+			case downloadFilename != "":
+				return fmt.Errorf("attachment not found: %s", downloadFilename)
+			default:
+				fmt.Println("No matching attachments found.")
 			}
+			return batchError(failed, len(ids))
 		}
 
+		downloadFailed, err := downloadJobs(client, jobs, store, manifest)
+		if err != nil {
+			return err
+		}
+
+		if failed > 0 || downloadFailed > 0 {
+			cmd.SilenceUsage = true
+		}
+		if err := batchError(failed, len(ids)); err != nil {
+			return err
+		}
+		if downloadFailed > 0 {
+			return fmt.Errorf("failed to download %d of %d attachments", downloadFailed, len(jobs))
+		}
 		return nil
 	},
 }
 
-func downloadAttachment(client *gmail.Client, messageID string, att *gmail.Attachment) ([]byte, error) {
+// downloadJob is one attachment to download and the path to save it to
+type downloadJob struct {
+	messageID string
+	att       *gmail.Attachment
+	path      string
+}
+
+// downloadJobs downloads jobs concurrently, printing each result as it
+// completes, and returns the number of failed downloads. With a manifest,
+// every result is recorded in it, and it is saved periodically while
+// downloading so an interrupted run can be resumed, and once more at the end. With a store,
+// duplicate content is linked or skipped according to --dedupe.
+func downloadJobs(client *gmail.Client, jobs []downloadJob, store *downloads.Store, manifest *downloads.Manifest) (int, error) {
+	// Progress bars of concurrent downloads would overwrite each other
+	showProgress := isTerminal(os.Stderr) && (len(jobs) == 1 || downloadWorkers == 1)
+
+	var mu sync.Mutex
+	var saveErr error
+	saver := newManifestSaver(manifest)
+	failed := 0
+	runConcurrently(len(jobs), downloadWorkers, func(i int) {
+		job := jobs[i]
+		size, hash, err := downloadJobFile(client, job, showProgress)
+
+		dedupe := dedupeNew
+		if err == nil && store != nil {
+			dedupe, err = dedupeFile(store, job.path, hash)
+		}
+		skipped := dedupe == dedupeSkipped
+
+		var extractDir string
+		var extractErr error
+		if err == nil && !skipped && downloadExtract && isZipFile(job.att.Filename, job.att.MimeType) {
+			extractDir = strings.TrimSuffix(job.path, filepath.Ext(job.path))
+			extractErr = ziputil.Extract(job.path, extractDir, ziputil.DefaultOptions())
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		entry := downloads.Entry{
+			MessageID:    job.messageID,
+			PartID:       job.att.PartID,
+			AttachmentID: job.att.AttachmentID,
+			Filename:     job.att.Filename,
+			Size:         job.att.Size,
+			Status:       downloads.StatusFailed,
+		}
+		if err == nil {
+			entry.Size, entry.SHA256, entry.Duplicate = size, hash, dedupe != dedupeNew
+			entry.Status = downloads.StatusComplete
+			if !skipped {
+				entry.Path = manifestPath(job.path)
+			}
+		} else {
+			entry.Error = err.Error()
+		}
+		if err := saver.record(entry); err != nil && saveErr == nil {
+			saveErr = err
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error downloading %s: %v\n", job.att.Filename, err)
+			failed++
+			return
+		}
+
+		switch dedupe {
+		case dedupeSkipped:
+			fmt.Printf("Skipped duplicate: %s (%s)\n", job.path, formatSize(size))
+		case dedupeLinked:
+			fmt.Printf("Linked duplicate: %s (%s)\n", job.path, formatSize(size))
+		case dedupeCopied:
+			fmt.Printf("Downloaded: %s (%s)\n", job.path, formatSize(size))
+			fmt.Fprintf(os.Stderr, "Warning: could not hard link duplicate %s, kept it as a copy\n", job.path)
+		default:
+			fmt.Printf("Downloaded: %s (%s)\n", job.path, formatSize(size))
+		}
+		if extractDir != "" {
+			if extractErr != nil {
+				fmt.Fprintf(os.Stderr, "Error extracting %s: %v\n", job.att.Filename, extractErr)
+			} else {
+				fmt.Printf("Extracted to: %s\n", extractDir)
+			}
+		}
+	})
+
+	if err := saver.flush(); err != nil && saveErr == nil {
+		saveErr = err
+	}
+	return failed, saveErr
+}
+
+const (
+	manifestSaveEntries  = 50
+	manifestSaveInterval = 2 * time.Second
+)
+
+// manifestSaver records entries in a manifest and saves it once
+// manifestSaveEntries entries are pending or manifestSaveInterval has
+// passed. Each save rewrites the whole manifest, so saving after every entry
+// would write O(n²) bytes over a large run. Without a manifest nothing is
+// recorded.
+type manifestSaver struct {
+	manifest *downloads.Manifest
+	pending  int
+	saved    time.Time
+	now      func() time.Time
+}
+
+func newManifestSaver(manifest *downloads.Manifest) *manifestSaver {
+	return &manifestSaver{manifest: manifest, saved: time.Now(), now: time.Now}
+}
+
+// record adds entry to the manifest and saves it if a save is due
+func (s *manifestSaver) record(entry downloads.Entry) error {
+	if s.manifest == nil {
+		return nil
+	}
+	s.manifest.Set(entry)
+	s.pending++
+	if s.pending < manifestSaveEntries && s.now().Sub(s.saved) < manifestSaveInterval {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush saves the manifest if any entries are pending
+func (s *manifestSaver) flush() error {
+	if s.pending == 0 {
+		return nil
+	}
+	s.pending = 0
+	s.saved = s.now()
+	return s.manifest.Save()
+}
+
+// isDownloaded reports whether a manifest entry records a completed download
+// whose file is still in place
+func isDownloaded(entry downloads.Entry) bool {
+	if !entry.Complete() {
+		return false
+	}
+	if entry.Path == "" {
+		// A skipped duplicate; its content is in the store
+		return entry.Duplicate
+	}
+	info, err := os.Stat(filepath.Join(downloadDir, filepath.FromSlash(entry.Path)))
+	return err == nil && info.Size() == entry.Size
+}
+
+// describeEntry names the file of a completed manifest entry
+func describeEntry(entry downloads.Entry) string {
+	if entry.Path == "" {
+		return entry.Filename + " (duplicate)"
+	}
+	return filepath.Join(downloadDir, filepath.FromSlash(entry.Path))
+}
+
+// downloadJobFile saves the attachment of job to its path and returns its
+// size and SHA-256
+func downloadJobFile(client *gmail.Client, job downloadJob, showProgress bool) (int64, string, error) {
+	if err := os.MkdirAll(filepath.Dir(job.path), 0755); err != nil {
+		return 0, "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	h := sha256.New()
+	size, err := saveAttachment(job.path, job.att.Size, func(w io.Writer) (int64, error) {
+		w = io.MultiWriter(w, h)
+		if showProgress {
+			progress := newProgressWriter(w, os.Stderr, job.att.Filename, job.att.Size)
+			defer progress.Finish()
+			w = progress
+		}
+		return downloadAttachment(client, job.messageID, job.att, w)
+	})
+	if err != nil {
+		return size, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Outcomes of dedupeFile
+const (
+	dedupeNew     = "new"
+	dedupeLinked  = "linked"
+	dedupeSkipped = "skipped"
+	// dedupeCopied is a duplicate kept as a full copy because the filesystem
+	// could not hard link it
+	dedupeCopied = "copied"
+)
+
+// dedupeFile adds a downloaded file to the content store. A file whose
+// content is already stored is replaced by a hard link to it or, with
+// --dedupe skip, removed. A duplicate that cannot be linked is kept as a
+// copy; only losing the file itself is an error.
+func dedupeFile(store *downloads.Store, path, hash string) (string, error) {
+	duplicate, err := store.Add(hash, path)
+	if err != nil {
+		return dedupeNew, err
+	}
+	if !duplicate {
+		return dedupeNew, nil
+	}
+	if downloadDedupe == "skip" {
+		if err := os.Remove(path); err != nil {
+			return dedupeSkipped, fmt.Errorf("failed to remove duplicate: %w", err)
+		}
+		return dedupeSkipped, nil
+	}
+	linked, err := store.LinkTo(hash, path)
+	if err != nil {
+		if _, statErr := os.Stat(path); statErr != nil {
+			return dedupeLinked, err
+		}
+		return dedupeCopied, nil
+	}
+	if !linked {
+		return dedupeCopied, nil
+	}
+	return dedupeLinked, nil
+}
+
+// manifestPath returns path relative to the output directory as recorded
+// in the manifest
+func manifestPath(path string) string {
+	if rel, err := filepath.Rel(downloadDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// downloadAttachment writes the decoded content of an attachment to w
+func downloadAttachment(client *gmail.Client, messageID string, att *gmail.Attachment, w io.Writer) (int64, error) {
 	if att.AttachmentID != "" {
-		return client.DownloadAttachment(messageID, att.AttachmentID)
+		return client.StreamAttachment(messageID, att.AttachmentID, w)
+	}
+	// Small parts carry their data in the message itself
+	data, err := client.DownloadInlineAttachment(messageID, att.PartID)
+	if err != nil {
+		return 0, err
 	}
-	return client.DownloadInlineAttachment(messageID, att.PartID)
+	n, err := w.Write(data)
+	return int64(n), err
 }
 
-func saveAttachment(path string, data []byte) error {
-	return os.WriteFile(path, data, 0644)
+// saveAttachment writes an attachment to path through a temporary file in
+// the same directory, which is renamed into place only once write has
+// succeeded and produced the expected size (when known). An interrupted or
+// failed download never leaves a partial file behind.
+func saveAttachment(path string, size int64, write func(w io.Writer) (int64, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	n, err := write(tmp)
+	if err != nil {
+		return n, err
+	}
+	if size > 0 && n != size {
+		return n, fmt.Errorf("size mismatch: got %d bytes, expected %d", n, size)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return n, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return n, fmt.Errorf("failed to save file: %w", err)
+	}
+	return n, nil
 }
 
 func isZipFile(filename, mimeType string) bool {
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/spf13/cobra"
+)
+
+// attachmentFilterFlags holds the attachment filter flags of a command
+type attachmentFilterFlags struct {
+	match         []string
+	regex         string
+	mimeTypes     []string
+	minSize       string
+	maxSize       string
+	excludeInline bool
+}
+
+// register adds the filter flags to cmd
+func (f *attachmentFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.match, "match", nil,
+		"Only attachments whose filename matches this glob, e.g. '*.pdf' (repeatable, case-insensitive)")
+	cmd.Flags().StringVar(&f.regex, "regex", "",
+		"Only attachments whose filename matches this regular expression")
+	cmd.Flags().StringSliceVar(&f.mimeTypes, "mime", nil,
+		"Only attachments with these MIME types; globs like 'image/*' are allowed")
+	cmd.Flags().StringVar(&f.minSize, "min-size", "",
+		"Only attachments at least this large, e.g. 100K or 2M")
+	cmd.Flags().StringVar(&f.maxSize, "max-size", "",
+		"Only attachments at most this large, e.g. 100K or 2M")
+	cmd.Flags().BoolVar(&f.excludeInline, "exclude-inline", false,
+		"Skip inline attachments such as embedded images")
+}
+
+// attachmentFilter selects attachments by their metadata, so nothing has to
+// be downloaded to decide
+type attachmentFilter struct {
+	filename      string
+	globs         []string
+	regex         *regexp.Regexp
+	mimeTypes     []string
+	minSize       int64
+	maxSize       int64
+	excludeInline bool
+}
+
+// compile validates the flags and builds the filter. filename is an exact
+// name to match, or empty.
+func (f *attachmentFilterFlags) compile(filename string) (*attachmentFilter, error) {
+	filter := &attachmentFilter{filename: filename, excludeInline: f.excludeInline, maxSize: -1}
+
+	for _, glob := range f.match {
+		glob = strings.ToLower(glob)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid --match pattern %q: %w", glob, err)
+		}
+		filter.globs = append(filter.globs, glob)
+	}
+
+	for _, mimeType := range f.mimeTypes {
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		if _, err := path.Match(mimeType, ""); err != nil {
+			return nil, fmt.Errorf("invalid --mime pattern %q: %w", mimeType, err)
+		}
+		filter.mimeTypes = append(filter.mimeTypes, mimeType)
+	}
+
+	if f.regex != "" {
+		re, err := regexp.Compile(f.regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --regex: %w", err)
+		}
+		filter.regex = re
+	}
+
+	var err error
+	if f.minSize != "" {
+		if filter.minSize, err = parseByteSize(f.minSize); err != nil {
+			return nil, fmt.Errorf("invalid --min-size: %w", err)
+		}
+	}
+	if f.maxSize != "" {
+		if filter.maxSize, err = parseByteSize(f.maxSize); err != nil {
+			return nil, fmt.Errorf("invalid --max-size: %w", err)
+		}
+		if filter.maxSize < filter.minSize {
+			return nil, fmt.Errorf("--max-size must not be smaller than --min-size")
+		}
+	}
+
+	return filter, nil
+}
+
+// selects reports whether the filter narrows the attachments down by name,
+// type or size
+func (f *attachmentFilter) selects() bool {
+	return f.filename != "" || len(f.globs) > 0 || f.regex != nil ||
+		len(f.mimeTypes) > 0 || f.minSize > 0 || f.maxSize >= 0
+}
+
+// matches reports whether att passes every filter
+func (f *attachmentFilter) matches(att *gmail.Attachment) bool {
+	if f.excludeInline && att.IsInline {
+		return false
+	}
+	if f.filename != "" && att.Filename != f.filename {
+		return false
+	}
+	if len(f.globs) > 0 && !matchesAny(f.globs, strings.ToLower(att.Filename)) {
+		return false
+	}
+	if f.regex != nil && !f.regex.MatchString(att.Filename) {
+		return false
+	}
+	if len(f.mimeTypes) > 0 && !matchesAny(f.mimeTypes, strings.ToLower(att.MimeType)) {
+		return false
+	}
+	if att.Size < f.minSize || (f.maxSize >= 0 && att.Size > f.maxSize) {
+		return false
+	}
+	return true
+}
+
+// apply returns the attachments that pass the filter
+func (f *attachmentFilter) apply(attachments []*gmail.Attachment) []*gmail.Attachment {
+	var matched []*gmail.Attachment
+	for _, att := range attachments {
+		if f.matches(att) {
+			matched = append(matched, att)
+		}
+	}
+	return matched
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var byteSizePattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([kmg]?)(?:i?b)?$`)
+
+// parseByteSize parses sizes like 500, 100K, 2.5MB or 1GiB. Units are
+// multiples of 1024, matching how sizes are displayed.
+func parseByteSize(s string) (int64, error) {
+	m := byteSizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a size like 500K or 5M", s)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a size like 500K or 5M", s)
+	}
+	switch strings.ToUpper(m[2]) {
+	case "K":
+		n *= 1 << 10
+	case "M":
+		n *= 1 << 20
+	case "G":
+		n *= 1 << 30
+	}
+	return int64(n), nil
+}
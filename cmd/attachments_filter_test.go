@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentFilter(t *testing.T) {
+	pdf := &gmail.Attachment{Filename: "Invoice-2024.PDF", MimeType: "application/pdf", Size: 200 << 10}
+	logo := &gmail.Attachment{Filename: "image001.png", MimeType: "image/png", Size: 4 << 10, IsInline: true}
+	photo := &gmail.Attachment{Filename: "photo.jpg", MimeType: "image/jpeg", Size: 3 << 20}
+	all := []*gmail.Attachment{pdf, logo, photo}
+
+	tests := []struct {
+		name     string
+		flags    attachmentFilterFlags
+		filename string
+		expected []*gmail.Attachment
+	}{
+		{"no filters", attachmentFilterFlags{}, "", all},
+		{"exact filename", attachmentFilterFlags{}, "photo.jpg", []*gmail.Attachment{photo}},
+		{"glob is case-insensitive", attachmentFilterFlags{match: []string{"*.pdf"}}, "", []*gmail.Attachment{pdf}},
+		{"any glob matches", attachmentFilterFlags{match: []string{"*.pdf", "*.jpg"}}, "", []*gmail.Attachment{pdf, photo}},
+		{"regex", attachmentFilterFlags{regex: `^image\d+`}, "", []*gmail.Attachment{logo}},
+		{"exact mime", attachmentFilterFlags{mimeTypes: []string{"Application/PDF"}}, "", []*gmail.Attachment{pdf}},
+		{"mime glob", attachmentFilterFlags{mimeTypes: []string{"image/*"}}, "", []*gmail.Attachment{logo, photo}},
+		{"min size", attachmentFilterFlags{minSize: "100K"}, "", []*gmail.Attachment{pdf, photo}},
+		{"max size", attachmentFilterFlags{maxSize: "1M"}, "", []*gmail.Attachment{pdf, logo}},
+		{"exclude inline", attachmentFilterFlags{excludeInline: true}, "", []*gmail.Attachment{pdf, photo}},
+		{"filters combine", attachmentFilterFlags{mimeTypes: []string{"image/*"}, excludeInline: true}, "", []*gmail.Attachment{photo}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.flags.compile(tt.filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter.apply(all))
+		})
+	}
+}
+
+func TestAttachmentFilterCompile(t *testing.T) {
+	invalid := []attachmentFilterFlags{
+		{match: []string{"[.pdf"}},
+		{regex: "("},
+		{mimeTypes: []string{"image/["}},
+		{minSize: "big"},
+		{maxSize: "-1"},
+		{minSize: "2M", maxSize: "1M"},
+	}
+	for _, flags := range invalid {
+		_, err := flags.compile("")
+		assert.Error(t, err, "%+v", flags)
+	}
+
+	t.Run("selects", func(t *testing.T) {
+		filter, err := (&attachmentFilterFlags{}).compile("")
+		require.NoError(t, err)
+		assert.False(t, filter.selects())
+
+		filter, err = (&attachmentFilterFlags{excludeInline: true}).compile("")
+		require.NoError(t, err)
+		assert.False(t, filter.selects(), "exclude-inline alone does not select")
+
+		filter, err = (&attachmentFilterFlags{maxSize: "0"}).compile("")
+		require.NoError(t, err)
+		assert.True(t, filter.selects())
+
+		filter, err = (&attachmentFilterFlags{}).compile("a.pdf")
+		require.NoError(t, err)
+		assert.True(t, filter.selects())
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"500", 500},
+		{"500B", 500},
+		{"100K", 100 << 10},
+		{"100kb", 100 << 10},
+		{"2.5M", 5 << 19},
+		{"1 GiB", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, got, tt.input)
+	}
+
+	for _, input := range []string{"", "K", "5X", "-1", "1.2.3M"} {
+		_, err := parseByteSize(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+)
+
+// defaultLayout saves attachments directly in the output directory
+const defaultLayout = "{{.Filename}}"
+
+// layoutData holds the values available to --layout templates. Every value
+// is sanitized to a single path component; only separators in the template
+// itself create directories.
+type layoutData struct {
+	Date      string
+	Year      string
+	Month     string
+	Day       string
+	From      string
+	FromName  string
+	Subject   string
+	MessageID string
+	ThreadID  string
+	Filename  string
+	PartID    string
+}
+
+// newLayoutData collects the layout values for an attachment of msg. Dates
+// use the --tz timezone.
+func newLayoutData(msg *gmail.Message, att *gmail.Attachment) layoutData {
+	data := layoutData{
+		Date:      "undated",
+		Year:      "undated",
+		Month:     "undated",
+		Day:       "undated",
+		From:      sanitizeFilename(msg.From),
+		FromName:  sanitizeFilename(msg.From),
+		Subject:   sanitizeFilename(msg.Subject),
+		MessageID: sanitizeFilename(msg.ID),
+		ThreadID:  sanitizeFilename(msg.ThreadID),
+		Filename:  sanitizeFilename(att.Filename),
+		PartID:    sanitizeFilename(att.PartID),
+	}
+
+	if strings.TrimSpace(att.Filename) == "" {
+		data.Filename = "attachment"
+	}
+
+	if msg.DateParsed != nil {
+		t := msg.DateParsed.In(displayLocation)
+		data.Date = t.Format("2006-01-02")
+		data.Year = t.Format("2006")
+		data.Month = t.Format("01")
+		data.Day = t.Format("02")
+	}
+
+	if len(msg.FromAddresses) > 0 {
+		from := msg.FromAddresses[0]
+		data.From = sanitizeFilename(from.Email)
+		data.FromName = data.From
+		if from.Name != "" {
+			data.FromName = sanitizeFilename(from.Name)
+		}
+	}
+
+	return data
+}
+
+// parseLayout parses a --layout template, rejecting unknown fields up front
+// rather than on the first attachment
+func parseLayout(layout string) (*template.Template, error) {
+	tmpl, err := template.New("layout").Parse(layout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, layoutData{}); err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderLayout returns the path of an attachment relative to the output
+// directory. Paths that would leave the output directory are rejected.
+func renderLayout(tmpl *template.Template, data layoutData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render layout: %w", err)
+	}
+
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimSpace(b.String())))
+	if rel == "." || rel == ".." || filepath.IsAbs(rel) ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("layout produced an invalid path: %q", b.String())
+	}
+	return rel, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLayoutData(t *testing.T) {
+	defer func(loc *time.Location) { displayLocation = loc }(displayLocation)
+	displayLocation = time.UTC
+
+	msg := &gmail.Message{
+		ID:            "18abc",
+		ThreadID:      "18abb",
+		Subject:       "Invoice 2024/01",
+		From:          "Billing Team <billing@example.com>",
+		FromAddresses: []gmail.Address{{Name: "Billing Team", Email: "billing@example.com"}},
+		DateParsed:    timePtr(time.Date(2024, 1, 31, 23, 30, 0, 0, time.UTC)),
+	}
+	att := &gmail.Attachment{Filename: "../invoice.pdf", PartID: "0.1"}
+
+	data := newLayoutData(msg, att)
+	assert.Equal(t, "2024-01-31", data.Date)
+	assert.Equal(t, "2024", data.Year)
+	assert.Equal(t, "01", data.Month)
+	assert.Equal(t, "31", data.Day)
+	assert.Equal(t, "billing@example.com", data.From)
+	assert.Equal(t, "Billing Team", data.FromName)
+	assert.Equal(t, "Invoice 2024_01", data.Subject)
+	assert.Equal(t, "18abc", data.MessageID)
+	assert.Equal(t, ".._invoice.pdf", data.Filename)
+
+	t.Run("uses the display timezone", func(t *testing.T) {
+		displayLocation = time.FixedZone("UTC+2", 2*60*60)
+		assert.Equal(t, "2024-02-01", newLayoutData(msg, att).Date)
+	})
+
+	t.Run("handles missing values", func(t *testing.T) {
+		data := newLayoutData(&gmail.Message{ID: "x"}, &gmail.Attachment{})
+		assert.Equal(t, "undated", data.Date)
+		assert.Equal(t, "_", data.From)
+		assert.Equal(t, "attachment", data.Filename)
+	})
+}
+
+func TestParseLayout(t *testing.T) {
+	_, err := parseLayout("{{.Date}}/{{.From}}/{{.Filename}}")
+	assert.NoError(t, err)
+
+	_, err = parseLayout("{{.Sender}}/{{.Filename}}")
+	assert.ErrorContains(t, err, "invalid layout")
+
+	_, err = parseLayout("{{.Filename")
+	assert.ErrorContains(t, err, "invalid layout")
+}
+
+func TestRenderLayout(t *testing.T) {
+	data := layoutData{Date: "2024-01-31", From: "billing@example.com", Filename: "invoice.pdf"}
+
+	tmpl, err := parseLayout("{{.Date}}/{{.From}}/{{.Filename}}")
+	require.NoError(t, err)
+	rel, err := renderLayout(tmpl, data)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("2024-01-31", "billing@example.com", "invoice.pdf"), rel)
+
+	for _, layout := range []string{"/etc/{{.Filename}}", "../{{.Filename}}", "a/../../{{.Filename}}", " "} {
+		tmpl, err := parseLayout(layout)
+		require.NoError(t, err)
+		_, err = renderLayout(tmpl, data)
+		assert.Error(t, err, layout)
+	}
+}
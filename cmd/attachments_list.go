@@ -1,16 +1,28 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"sync"
 
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/spf13/cobra"
 )
 
-var listAttachmentsJSON bool
+var (
+	listAttachmentsJSON    bool
+	listAttachmentsFilters attachmentFilterFlags
+	listAttachmentsHash    bool
+)
 
 func init() {
 	attachmentsCmd.AddCommand(listAttachmentsCmd)
 	listAttachmentsCmd.Flags().BoolVarP(&listAttachmentsJSON, "json", "j", false, "Output as JSON")
+	listAttachmentsCmd.Flags().BoolVar(&listAttachmentsHash, "hash", false,
+		"Download each attachment to compute its SHA-256 (nothing is saved)")
+	listAttachmentsFilters.register(listAttachmentsCmd)
 }
 
 var listAttachmentsCmd = &cobra.Command{
@@ -20,11 +32,27 @@ var listAttachmentsCmd = &cobra.Command{
 
 Shows filename, MIME type, size, and whether the attachment is inline.
 
+The same filters as 'attachments download' narrow the list: --match
+(filename glob), --regex, --mime (type or glob such as 'image/*'),
+--min-size, --max-size and --exclude-inline.
+
+--hash streams each listed attachment to compute its SHA-256 without saving
+it, e.g. to find files already downloaded; the hash is included as sha256
+in JSON output.
+
 Examples:
   gmro attachments list 18abc123def456
-  gmro attachments list 18abc123def456 --json`,
+  gmro attachments list 18abc123def456 --json
+  gmro attachments list 18abc123def456 --match '*.pdf'
+  gmro attachments list 18abc123def456 --mime 'image/*' --exclude-inline
+  gmro attachments list 18abc123def456 --hash --json`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := listAttachmentsFilters.compile("")
+		if err != nil {
+			return err
+		}
+
 		client, err := newGmailClient()
 		if err != nil {
 			return err
@@ -40,8 +68,25 @@ Examples:
 			return nil
 		}
 
+		attachments = filter.apply(attachments)
+		if len(attachments) == 0 {
+			if listAttachmentsJSON {
+				return printJSON([]any{})
+			}
+			fmt.Println("No matching attachments found.")
+			return nil
+		}
+
+		var hashErr error
+		if listAttachmentsHash {
+			hashErr = hashAttachments(client, args[0], attachments)
+		}
+
 		if listAttachmentsJSON {
-			return printJSON(attachments)
+			if err := printJSON(attachments); err != nil {
+				return err
+			}
+			return hashErr
 		}
 
 		fmt.Printf("Found %d attachment(s):\n\n", len(attachments))
@@ -52,13 +97,42 @@ Examples:
 			if att.IsInline {
 				fmt.Printf("   Inline: yes\n")
 			}
+			if att.SHA256 != "" {
+				fmt.Printf("   SHA-256: %s\n", att.SHA256)
+			}
 			fmt.Println()
 		}
 
-		return nil
+		return hashErr
 	},
 }
 
+// hashAttachments sets the SHA-256 of each attachment by streaming its
+// content. Failures are reported on stderr and leave the hash empty.
+func hashAttachments(client *gmail.Client, messageID string, attachments []*gmail.Attachment) error {
+	var mu sync.Mutex
+	failed := 0
+	runConcurrently(len(attachments), defaultConcurrency, func(i int) {
+		att := attachments[i]
+		h := sha256.New()
+		_, err := downloadAttachment(client, messageID, att, h)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error hashing %s: %v\n", att.Filename, err)
+			failed++
+			return
+		}
+		att.SHA256 = hex.EncodeToString(h.Sum(nil))
+	})
+
+	if failed > 0 {
+		return fmt.Errorf("failed to hash %d of %d attachments", failed, len(attachments))
+	}
+	return nil
+}
+
 // formatSize converts bytes to human-readable format
 func formatSize(bytes int64) string {
 	const unit = 1024
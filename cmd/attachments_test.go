@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/open-cli-collective/gmail-ro/internal/downloads"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsZipFile(t *testing.T) {
@@ -57,6 +65,72 @@ func TestFormatSize(t *testing.T) {
 	}
 }
 
+func TestSaveAttachment(t *testing.T) {
+	write := func(data string, err error) func(io.Writer) (int64, error) {
+		return func(w io.Writer) (int64, error) {
+			n, werr := io.WriteString(w, data)
+			if err != nil {
+				return int64(n), err
+			}
+			return int64(n), werr
+		}
+	}
+	// dirEntries lists the directory to check no temporary files are left
+	dirEntries := func(t *testing.T, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	t.Run("writes the file atomically", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "report.pdf")
+
+		n, err := saveAttachment(path, 5, write("hello", nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+		assert.Equal(t, []string{"report.pdf"}, dirEntries(t, dir))
+	})
+
+	t.Run("skips the size check when the size is unknown", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "a.txt")
+		_, err := saveAttachment(path, 0, write("hello", nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects a size mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := saveAttachment(filepath.Join(dir, "a.txt"), 10, write("hello", nil))
+		assert.ErrorContains(t, err, "size mismatch: got 5 bytes, expected 10")
+		assert.Empty(t, dirEntries(t, dir))
+	})
+
+	t.Run("keeps an existing file when the download fails", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "a.txt")
+		require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+		_, err := saveAttachment(path, 0, write("partial", errors.New("connection reset")))
+		assert.EqualError(t, err, "connection reset")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+		assert.Equal(t, []string{"a.txt"}, dirEntries(t, dir))
+	})
+}
+
 func TestAttachmentsCommand(t *testing.T) {
 	t.Run("has correct use", func(t *testing.T) {
 		assert.Equal(t, "attachments", attachmentsCmd.Use)
@@ -93,19 +167,35 @@ func TestListAttachmentsCommand(t *testing.T) {
 		assert.NotNil(t, flag)
 		assert.Equal(t, "j", flag.Shorthand)
 	})
+
+	t.Run("has hash flag", func(t *testing.T) {
+		flag := listAttachmentsCmd.Flags().Lookup("hash")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "false", flag.DefValue)
+	})
+
+	t.Run("has filter flags", func(t *testing.T) {
+		for _, name := range []string{"match", "regex", "mime", "min-size", "max-size", "exclude-inline"} {
+			assert.NotNil(t, listAttachmentsCmd.Flags().Lookup(name), "flag %s should exist", name)
+		}
+	})
 }
 
 func TestDownloadAttachmentsCommand(t *testing.T) {
 	t.Run("has correct use", func(t *testing.T) {
-		assert.Equal(t, "download <message-id>", downloadAttachmentsCmd.Use)
+		assert.Equal(t, "download [message-id]", downloadAttachmentsCmd.Use)
 	})
 
-	t.Run("requires exactly one argument", func(t *testing.T) {
+	t.Run("accepts at most one argument", func(t *testing.T) {
+		// No argument is valid with --query
 		err := downloadAttachmentsCmd.Args(downloadAttachmentsCmd, []string{})
-		assert.Error(t, err)
+		assert.NoError(t, err)
 
 		err = downloadAttachmentsCmd.Args(downloadAttachmentsCmd, []string{"msg123"})
 		assert.NoError(t, err)
+
+		err = downloadAttachmentsCmd.Args(downloadAttachmentsCmd, []string{"msg1", "msg2"})
+		assert.Error(t, err)
 	})
 
 	t.Run("has required flags", func(t *testing.T) {
@@ -117,6 +207,19 @@ func TestDownloadAttachmentsCommand(t *testing.T) {
 			{"output", "o"},
 			{"extract", "e"},
 			{"all", "a"},
+			{"query", "q"},
+			{"max", "m"},
+			{"mime", ""},
+			{"layout", ""},
+			{"concurrency", ""},
+			{"on-conflict", ""},
+			{"match", ""},
+			{"regex", ""},
+			{"min-size", ""},
+			{"max-size", ""},
+			{"exclude-inline", ""},
+			{"dedupe", ""},
+			{"no-resume", ""},
 		}
 
 		for _, f := range flags {
@@ -126,3 +229,139 @@ func TestDownloadAttachmentsCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestDedupeFile(t *testing.T) {
+	defer func(mode string) { downloadDedupe = mode }(downloadDedupe)
+	const hash = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	setup := func(t *testing.T) (*downloads.Store, string, string) {
+		dir := t.TempDir()
+		first, second := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+		require.NoError(t, os.WriteFile(first, []byte("hello"), 0644))
+		require.NoError(t, os.WriteFile(second, []byte("hello"), 0644))
+		store := downloads.NewStore(dir)
+		outcome, err := dedupeFile(store, first, hash)
+		require.NoError(t, err)
+		require.Equal(t, dedupeNew, outcome)
+		return store, first, second
+	}
+
+	t.Run("link replaces duplicates with hard links", func(t *testing.T) {
+		downloadDedupe = "link"
+		store, first, second := setup(t)
+
+		outcome, err := dedupeFile(store, second, hash)
+		require.NoError(t, err)
+		assert.Equal(t, dedupeLinked, outcome)
+
+		a, err := os.Stat(first)
+		require.NoError(t, err)
+		b, err := os.Stat(second)
+		require.NoError(t, err)
+		object, err := os.Stat(store.ObjectPath(hash))
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(object, b))
+		assert.True(t, os.SameFile(object, a), "the first copy is linked into the store")
+	})
+
+	t.Run("link keeps duplicates it cannot replace", func(t *testing.T) {
+		downloadDedupe = "link"
+		store, _, second := setup(t)
+		// A non-empty directory cannot be replaced by the link
+		require.NoError(t, os.Remove(second))
+		require.NoError(t, os.MkdirAll(filepath.Join(second, "keep"), 0755))
+
+		outcome, err := dedupeFile(store, second, hash)
+		require.NoError(t, err)
+		assert.Equal(t, dedupeCopied, outcome)
+		assert.DirExists(t, filepath.Join(second, "keep"))
+	})
+
+	t.Run("skip removes duplicates", func(t *testing.T) {
+		downloadDedupe = "skip"
+		store, first, second := setup(t)
+
+		outcome, err := dedupeFile(store, second, hash)
+		require.NoError(t, err)
+		assert.Equal(t, dedupeSkipped, outcome)
+		assert.FileExists(t, first)
+		assert.NoFileExists(t, second)
+	})
+}
+
+func TestManifestPath(t *testing.T) {
+	defer func(dir string) { downloadDir = dir }(downloadDir)
+	downloadDir = filepath.Join("out", "invoices")
+
+	assert.Equal(t, "2024/a.pdf", manifestPath(filepath.Join("out", "invoices", "2024", "a.pdf")))
+}
+
+func TestIsDownloaded(t *testing.T) {
+	defer func(dir string) { downloadDir = dir }(downloadDir)
+	downloadDir = t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(downloadDir, "2024"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(downloadDir, "2024", "a.pdf"), []byte("hello"), 0644))
+
+	tests := []struct {
+		name     string
+		entry    downloads.Entry
+		expected bool
+	}{
+		{"complete with file in place", downloads.Entry{Path: "2024/a.pdf", Size: 5, Status: downloads.StatusComplete}, true},
+		{"failed", downloads.Entry{Path: "2024/a.pdf", Size: 5, Status: downloads.StatusFailed}, false},
+		{"file missing", downloads.Entry{Path: "2024/b.pdf", Size: 5, Status: downloads.StatusComplete}, false},
+		{"size changed", downloads.Entry{Path: "2024/a.pdf", Size: 9, Status: downloads.StatusComplete}, false},
+		{"skipped duplicate", downloads.Entry{Duplicate: true, Status: downloads.StatusComplete}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isDownloaded(tt.entry))
+		})
+	}
+
+	assert.Equal(t, filepath.Join(downloadDir, "2024", "a.pdf"), describeEntry(downloads.Entry{Path: "2024/a.pdf"}))
+	assert.Equal(t, "logo.png (duplicate)", describeEntry(downloads.Entry{Filename: "logo.png", Duplicate: true}))
+}
+
+func TestManifestSaver(t *testing.T) {
+	path := downloads.ManifestPath(t.TempDir())
+	manifest, err := downloads.LoadManifest(path)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	saver := newManifestSaver(manifest)
+	saver.saved, saver.now = now, func() time.Time { return now }
+
+	entry := func(i int) downloads.Entry {
+		return downloads.Entry{MessageID: "msg", PartID: strconv.Itoa(i), Status: downloads.StatusComplete}
+	}
+	saved := func() int {
+		m, err := downloads.LoadManifest(path)
+		require.NoError(t, err)
+		return len(m.Entries())
+	}
+
+	for i := 0; i < manifestSaveEntries-1; i++ {
+		require.NoError(t, saver.record(entry(i)))
+	}
+	assert.Equal(t, 0, saved(), "nothing is saved before a batch is full")
+
+	require.NoError(t, saver.record(entry(manifestSaveEntries)))
+	assert.Equal(t, manifestSaveEntries, saved())
+
+	now = now.Add(manifestSaveInterval)
+	require.NoError(t, saver.record(entry(manifestSaveEntries+1)))
+	assert.Equal(t, manifestSaveEntries+1, saved(), "a save is due after the interval")
+
+	require.NoError(t, saver.record(entry(manifestSaveEntries+2)))
+	require.NoError(t, saver.flush())
+	assert.Equal(t, manifestSaveEntries+2, saved())
+}
+
+func TestManifestSaverWithoutManifest(t *testing.T) {
+	saver := newManifestSaver(nil)
+	for i := 0; i <= manifestSaveEntries; i++ {
+		require.NoError(t, saver.record(downloads.Entry{MessageID: "msg", PartID: strconv.Itoa(i)}))
+	}
+	require.NoError(t, saver.flush())
+}
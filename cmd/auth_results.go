@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/mailauth"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authResultsJSONOutput bool
+	authResultsVerify     bool
+	authResultsKeyFile    string
+	authResultsAuthServID string
+)
+
+func init() {
+	rootCmd.AddCommand(authResultsCmd)
+	authResultsCmd.Flags().BoolVarP(&authResultsJSONOutput, "json", "j", false, "Output as JSON")
+	authResultsCmd.Flags().BoolVar(&authResultsVerify, "verify", false,
+		"Verify DKIM signatures locally from the raw message")
+	authResultsCmd.Flags().StringVar(&authResultsKeyFile, "keys", "",
+		"Read DKIM public keys from this file instead of DNS (implies --verify)")
+	authResultsCmd.Flags().StringVar(&authResultsAuthServID, "authserv-id", mailauth.DefaultAuthServID,
+		"Only trust Authentication-Results headers from this receiving service")
+}
+
+var authResultsCmd = &cobra.Command{
+	Use:   "auth-results <message-id>",
+	Short: "Show SPF, DKIM, DMARC and ARC results for a message",
+	Long: `Summarize the email authentication results of a Gmail message.
+
+Parses the Authentication-Results, Received-SPF, DKIM-Signature and ARC-*
+headers into a verdict per mechanism, including the signing domains and
+whether each domain aligns with the From domain (relaxed alignment).
+
+The verdict is based on the topmost Authentication-Results header whose
+authserv-id is that of the receiving service (mx.google.com unless
+--authserv-id says otherwise), which is the one Gmail added on receipt.
+Other Authentication-Results headers are listed for reference but can be
+forged by the sender. Without a header from the receiving service the
+verdict is marked unverified, and Received-SPF headers are not used.
+
+With --verify, gmro also fetches the raw message and verifies each DKIM
+signature itself (body hash and RSA or Ed25519 signature), independently of
+Gmail's verdict. Signatures using rsa-sha1, which RFC 8301 retired, are
+reported as permerror, and signatures past their x= expiry fail. Public
+keys are looked up in DNS, or read from a file with --keys for offline
+use. Each line of the key file holds a record name and its TXT value:
+
+  sel._domainkey.example.com "v=DKIM1; k=rsa; p=MIIBIjANBg..."
+
+Examples:
+  gmro auth-results 18abc123def456
+  gmro auth-results 18abc123def456 --json
+  gmro auth-results 18abc123def456 --verify
+  gmro auth-results 18abc123def456 --keys dkim-keys.txt --json
+  gmro auth-results 18abc123def456 --authserv-id mx.example.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newGmailClient()
+		if err != nil {
+			return err
+		}
+
+		headers, err := client.GetHeaders(args[0])
+		if err != nil {
+			return err
+		}
+
+		report := mailauth.Analyze(toMailauthHeaders(headers), authResultsAuthServID)
+
+		if authResultsVerify || authResultsKeyFile != "" {
+			var resolver mailauth.KeyResolver = mailauth.DNSResolver{}
+			if authResultsKeyFile != "" {
+				keys, err := mailauth.LoadKeyFile(authResultsKeyFile)
+				if err != nil {
+					return err
+				}
+				resolver = keys
+			}
+
+			raw, err := client.GetRawMessage(args[0])
+			if err != nil {
+				return err
+			}
+			report.LocalDKIM, err = mailauth.VerifyDKIM(raw, resolver, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to verify DKIM: %w", err)
+			}
+		}
+
+		if authResultsJSONOutput {
+			return printJSON(report)
+		}
+
+		printAuthReport(report)
+		return nil
+	},
+}
+
+// toMailauthHeaders converts Gmail headers for the mailauth package
+func toMailauthHeaders(headers []gmail.Header) []mailauth.Header {
+	result := make([]mailauth.Header, len(headers))
+	for i, h := range headers {
+		result[i] = mailauth.Header{Name: h.Name, Value: h.Value}
+	}
+	return result
+}
+
+func printAuthReport(r *mailauth.Report) {
+	fmt.Printf("From:   %s\n", r.From)
+	if r.FromDomain != "" {
+		fmt.Printf("Domain: %s\n", r.FromDomain)
+	}
+	if r.Verified {
+		fmt.Printf("Source: %s\n", r.AuthServID)
+	} else {
+		fmt.Println("Source: unverified (no Authentication-Results header from the receiving service)")
+	}
+	fmt.Println()
+
+	printVerdict("SPF", r.SPF)
+	if len(r.DKIM) == 0 {
+		printVerdict("DKIM", mailauth.Verdict{Result: "none"})
+	}
+	for _, v := range r.DKIM {
+		printVerdict("DKIM", v)
+	}
+	printVerdict("DMARC", r.DMARC)
+	printVerdict("ARC", r.ARC)
+
+	if len(r.DKIMSignatures) > 0 {
+		fmt.Println()
+		fmt.Println("DKIM signatures:")
+		for _, sig := range r.DKIMSignatures {
+			fmt.Printf("  d=%s s=%s a=%s aligned=%s\n",
+				sig.Domain, sig.Selector, sig.Algorithm, yesNo(sig.Aligned))
+		}
+	}
+
+	if len(r.LocalDKIM) > 0 {
+		fmt.Println()
+		fmt.Println("Local DKIM verification:")
+		for _, v := range r.LocalDKIM {
+			line := fmt.Sprintf("  %-9s d=%s s=%s a=%s", v.Result, v.Domain, v.Selector, v.Algorithm)
+			if v.Reason != "" {
+				line += fmt.Sprintf(" (%s)", v.Reason)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if len(r.ARCSets) > 0 {
+		fmt.Println()
+		fmt.Println("ARC chain:")
+		for _, set := range r.ARCSets {
+			fmt.Printf("  i=%d cv=%s sealed by %s\n", set.Instance, set.ChainValidation, set.SealDomain)
+		}
+	}
+
+	var untrusted []string
+	skipTrusted := r.Verified
+	for _, ar := range r.AuthenticationResults {
+		// Only the topmost header from the receiving service is trusted
+		if skipTrusted && strings.EqualFold(ar.AuthServID, r.AuthServID) {
+			skipTrusted = false
+			continue
+		}
+		untrusted = append(untrusted, ar.AuthServID)
+	}
+	if len(untrusted) > 0 {
+		fmt.Println()
+		fmt.Println("Note: these Authentication-Results headers were not added by the")
+		fmt.Println("receiving service and are not trusted:")
+		for _, id := range untrusted {
+			fmt.Printf("  %s\n", id)
+		}
+	}
+}
+
+func printVerdict(mechanism string, v mailauth.Verdict) {
+	line := fmt.Sprintf("%-6s %-10s", mechanism+":", v.Result)
+	if v.Domain != "" {
+		line += fmt.Sprintf(" domain=%s", v.Domain)
+	}
+	if v.Selector != "" {
+		line += fmt.Sprintf(" selector=%s", v.Selector)
+	}
+	if v.Domain != "" && mechanism != "ARC" {
+		line += fmt.Sprintf(" aligned=%s", yesNo(v.Aligned))
+	}
+	if v.Comment != "" {
+		line += fmt.Sprintf(" (%s)", v.Comment)
+	}
+	fmt.Println(line)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/mailauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthResultsCommand(t *testing.T) {
+	t.Run("has correct use", func(t *testing.T) {
+		assert.Equal(t, "auth-results <message-id>", authResultsCmd.Use)
+	})
+
+	t.Run("requires exactly one argument", func(t *testing.T) {
+		assert.Error(t, authResultsCmd.Args(authResultsCmd, []string{}))
+		assert.NoError(t, authResultsCmd.Args(authResultsCmd, []string{"msg123"}))
+	})
+
+	t.Run("has json flag", func(t *testing.T) {
+		flag := authResultsCmd.Flags().Lookup("json")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "j", flag.Shorthand)
+	})
+
+	t.Run("has verification flags", func(t *testing.T) {
+		assert.NotNil(t, authResultsCmd.Flags().Lookup("verify"))
+		assert.NotNil(t, authResultsCmd.Flags().Lookup("keys"))
+	})
+
+	t.Run("trusts mx.google.com by default", func(t *testing.T) {
+		flag := authResultsCmd.Flags().Lookup("authserv-id")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "mx.google.com", flag.DefValue)
+	})
+}
+
+func TestToMailauthHeaders(t *testing.T) {
+	headers := []gmail.Header{{Name: "From", Value: "a@example.com"}, {Name: "Subject", Value: "Hi"}}
+	assert.Equal(t, []mailauth.Header{
+		{Name: "From", Value: "a@example.com"},
+		{Name: "Subject", Value: "Hi"},
+	}, toMailauthHeaders(headers))
+}
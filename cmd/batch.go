@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// defaultConcurrency is how many IDs batch commands fetch at the same time
+const defaultConcurrency = 4
+
+// collectIDs expands command arguments into the list of IDs to fetch. An
+// argument of "-" is replaced by the IDs read from stdin, one per line, so
+// that search results can be piped in. Blank lines are ignored.
+func collectIDs(args []string, stdin io.Reader) ([]string, error) {
+	var ids []string
+	for _, arg := range args {
+		if arg != "-" {
+			ids = append(ids, arg)
+			continue
+		}
+
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if id := strings.TrimSpace(scanner.Text()); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read IDs from stdin: %w", err)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no IDs given")
+	}
+	return ids, nil
+}
+
+// validateConcurrency checks a --concurrency flag value
+func validateConcurrency(n int) error {
+	if n < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", n)
+	}
+	return nil
+}
+
+// fetchResult is the outcome of fetching one ID
+type fetchResult[T any] struct {
+	ID    string
+	Value T
+	Err   error
+}
+
+// fetchAll calls fetch for every ID using up to workers goroutines. Results
+// are returned in the order of ids regardless of which fetch finishes first.
+func fetchAll[T any](ids []string, workers int, fetch func(id string) (T, error)) []fetchResult[T] {
+	results := make([]fetchResult[T], len(ids))
+	runConcurrently(len(ids), workers, func(i int) {
+		value, err := fetch(ids[i])
+		results[i] = fetchResult[T]{ID: ids[i], Value: value, Err: err}
+	})
+	return results
+}
+
+// runConcurrently calls fn for every index in [0, n) using up to workers
+// goroutines and returns once all calls have finished
+func runConcurrently(n, workers int, fn func(i int)) {
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// reportFetchErrors writes one line per failed ID to w and returns the
+// number of failures
+func reportFetchErrors[T any](w io.Writer, results []fetchResult[T]) int {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "Error: %s: %v\n", r.ID, r.Err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// printBatchJSON prints the result of a single ID as is and the results of
+// a batch as an array, so single-ID output is unchanged
+func printBatchJSON[T any](values []T, batch bool) error {
+	if !batch && len(values) == 1 {
+		return printJSON(values[0])
+	}
+	return printJSON(values)
+}
+
+// batchError summarizes failed fetches once the successful results have
+// been printed, so the command exits non-zero without hiding partial output
+func batchError(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to fetch %d of %d IDs", failed, total)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectIDs(t *testing.T) {
+	t.Run("uses arguments in order", func(t *testing.T) {
+		ids, err := collectIDs([]string{"b", "a"}, strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, ids)
+	})
+
+	t.Run("reads stdin in place of a dash", func(t *testing.T) {
+		stdin := strings.NewReader("id1\n\n  id2  \r\nid3\n")
+		ids, err := collectIDs([]string{"first", "-", "last"}, stdin)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "id1", "id2", "id3", "last"}, ids)
+	})
+
+	t.Run("rejects empty input", func(t *testing.T) {
+		_, err := collectIDs([]string{"-"}, strings.NewReader("\n\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateConcurrency(t *testing.T) {
+	assert.NoError(t, validateConcurrency(1))
+	assert.Error(t, validateConcurrency(0))
+}
+
+func TestFetchAll(t *testing.T) {
+	t.Run("keeps input order", func(t *testing.T) {
+		ids := []string{"slow", "fast", "missing", "medium"}
+		delays := map[string]time.Duration{"slow": 30 * time.Millisecond, "medium": 10 * time.Millisecond}
+
+		results := fetchAll(ids, 4, func(id string) (string, error) {
+			time.Sleep(delays[id])
+			if id == "missing" {
+				return "", errors.New("not found")
+			}
+			return strings.ToUpper(id), nil
+		})
+
+		require.Len(t, results, 4)
+		for i, id := range ids {
+			assert.Equal(t, id, results[i].ID)
+		}
+		assert.Equal(t, "SLOW", results[0].Value)
+		assert.Equal(t, "FAST", results[1].Value)
+		assert.EqualError(t, results[2].Err, "not found")
+		assert.Equal(t, "MEDIUM", results[3].Value)
+	})
+
+	t.Run("limits concurrent fetches", func(t *testing.T) {
+		var running, peak int32
+		ids := []string{"a", "b", "c", "d", "e", "f"}
+
+		fetchAll(ids, 2, func(id string) (string, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return id, nil
+		})
+
+		assert.LessOrEqual(t, peak, int32(2))
+	})
+}
+
+func TestReportFetchErrors(t *testing.T) {
+	results := []fetchResult[string]{
+		{ID: "a", Value: "ok"},
+		{ID: "b", Err: errors.New("failed to get message: 404")},
+	}
+
+	var buf bytes.Buffer
+	assert.Equal(t, 1, reportFetchErrors(&buf, results))
+	assert.Equal(t, "Error: b: failed to get message: 404\n", buf.String())
+}
+
+func TestBatchError(t *testing.T) {
+	assert.NoError(t, batchError(0, 3))
+	assert.EqualError(t, batchError(2, 3), "failed to fetch 2 of 3 IDs")
+}
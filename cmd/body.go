@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/htmlconv"
+	"github.com/open-cli-collective/gmail-ro/internal/quotes"
+)
+
+// bodyModes are the accepted values of --body
+var bodyModes = []string{"auto", "text", "html", "markdown"}
+
+// bodyFlagUsage is the help text for --body on read and thread
+var bodyFlagUsage = "Body to show: " + strings.Join(bodyModes, "|") +
+	" (auto uses the plain text part, rendering HTML only when there is none)"
+
+// validateBodyMode checks a --body value
+func validateBodyMode(mode string) error {
+	for _, m := range bodyModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid body mode %q: must be one of %s", mode, strings.Join(bodyModes, ", "))
+}
+
+// applyBodyMode replaces the message body with every displayable part,
+// rendered as selected by --body and joined in order. Auto mode picks the
+// plain text alternative and renders HTML-only parts as text; text mode picks
+// the HTML alternative and renders it as text; html mode returns raw HTML;
+// markdown mode converts HTML parts to Markdown.
+func applyBodyMode(msg *gmail.Message, mode string) {
+	parts := gmail.DisplayParts(msg.Parts, mode != "auto")
+	if len(parts) == 0 {
+		// Every text part was emptied, e.g. by --strip-quotes
+		if len(msg.Parts) > 0 {
+			msg.Body = ""
+		}
+		return
+	}
+
+	hasHTML := false
+	for _, p := range parts {
+		if p.MimeType == "text/html" {
+			hasHTML = true
+		}
+	}
+
+	rendered := make([]string, 0, len(parts))
+	for _, p := range parts {
+		rendered = append(rendered, renderPart(msg, p, mode, hasHTML))
+	}
+	msg.Body = strings.Join(rendered, "\n\n")
+
+	switch {
+	case !hasHTML:
+		msg.BodyMimeType = "text/plain"
+	case mode == "html":
+		msg.BodyMimeType = "text/html"
+	case mode == "markdown":
+		msg.BodyMimeType = "text/markdown"
+	default:
+		msg.BodyMimeType = "text/plain"
+	}
+}
+
+// renderPart renders one text part for the given body mode. In html mode,
+// plain parts next to HTML parts are escaped so the result is valid HTML.
+func renderPart(msg *gmail.Message, p *gmail.Part, mode string, hasHTML bool) string {
+	if p.MimeType != "text/html" {
+		if mode == "html" && hasHTML {
+			return "<pre>" + html.EscapeString(p.Content) + "</pre>"
+		}
+		return strings.TrimRight(p.Content, "\r\n")
+	}
+
+	switch mode {
+	case "html":
+		return p.Content
+	case "markdown":
+		return toMarkdownBody(msg, p.Content)
+	default:
+		return htmlconv.ToText(p.Content)
+	}
+}
+
+// stripQuotes removes quoted history and signatures from every text part of
+// a message. The text removed from the parts that make up the displayed body
+// is kept in StrippedText. All parts are stripped so that an emptied
+// alternative does not bring the quoted history back through another one, and
+// so is the unrendered body that JSON output carries.
+func stripQuotes(msg *gmail.Message, mode string) {
+	displayed := make(map[*gmail.Part]bool)
+	for _, p := range gmail.DisplayParts(msg.Parts, mode != "auto") {
+		displayed[p] = true
+	}
+
+	var removed []string
+	var walk func([]*gmail.Part)
+	walk = func(parts []*gmail.Part) {
+		for _, p := range parts {
+			walk(p.Parts)
+			if !p.IsText() {
+				continue
+			}
+
+			var stripped string
+			if p.MimeType == "text/html" {
+				p.Content, stripped = quotes.StripHTML(p.Content)
+				stripped = htmlconv.ToText(stripped)
+			} else {
+				p.Content, stripped = quotes.Strip(p.Content)
+			}
+			if displayed[p] && stripped != "" {
+				removed = append(removed, stripped)
+			}
+		}
+	}
+	walk(msg.Parts)
+
+	if msg.BodyMimeType == "text/html" {
+		msg.Body, _ = quotes.StripHTML(msg.Body)
+	} else {
+		msg.Body, _ = quotes.Strip(msg.Body)
+	}
+	msg.StrippedText = strings.Join(removed, "\n\n")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBodyMode(t *testing.T) {
+	for _, mode := range bodyModes {
+		assert.NoError(t, validateBodyMode(mode))
+	}
+	assert.Error(t, validateBodyMode("markup"))
+	assert.Error(t, validateBodyMode(""))
+}
+
+func TestApplyBodyMode(t *testing.T) {
+	htmlOnly := func() *gmail.Message {
+		return &gmail.Message{
+			Body: "<p>Hi <b>there</b></p>", BodyMimeType: "text/html",
+			Parts: []*gmail.Part{{MimeType: "text/html", Content: "<p>Hi <b>there</b></p>"}},
+		}
+	}
+	alternative := func() *gmail.Message {
+		return &gmail.Message{
+			Body: "Plain", BodyMimeType: "text/plain",
+			Parts: []*gmail.Part{{MimeType: "multipart/alternative", Parts: []*gmail.Part{
+				{PartID: "0", MimeType: "text/plain", Content: "Plain\r\n"},
+				{PartID: "1", MimeType: "text/html", Content: "<p>Rich</p>"},
+			}}},
+		}
+	}
+	mixed := func() *gmail.Message {
+		return &gmail.Message{
+			Body: "Intro", BodyMimeType: "text/plain",
+			Parts: []*gmail.Part{{MimeType: "multipart/mixed", Parts: []*gmail.Part{
+				{PartID: "0", MimeType: "text/plain", Content: "Intro <x>"},
+				{PartID: "1", MimeType: "text/html", Content: "<p>Forwarded</p>"},
+				{PartID: "2", MimeType: "text/plain", Content: "-- footer"},
+			}}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		msg          *gmail.Message
+		mode         string
+		expected     string
+		expectedType string
+	}{
+		{"auto renders html-only", htmlOnly(), "auto", "Hi there", "text/plain"},
+		{"auto keeps plain part", alternative(), "auto", "Plain", "text/plain"},
+		{"text renders html part", alternative(), "text", "Rich", "text/plain"},
+		{"html returns raw html", alternative(), "html", "<p>Rich</p>", "text/html"},
+		{"markdown converts html part", alternative(), "markdown", "Rich", "text/markdown"},
+		{"auto joins mixed parts", mixed(), "auto", "Intro <x>\n\nForwarded\n\n-- footer", "text/plain"},
+		{"html escapes plain parts", mixed(), "html", "<pre>Intro &lt;x&gt;</pre>\n\n<p>Forwarded</p>\n\n<pre>-- footer</pre>", "text/html"},
+		{"no parts leaves body", &gmail.Message{Body: "Plain", BodyMimeType: "text/plain"}, "html", "Plain", "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyBodyMode(tt.msg, tt.mode)
+			assert.Equal(t, tt.expected, tt.msg.Body)
+			assert.Equal(t, tt.expectedType, tt.msg.BodyMimeType)
+		})
+	}
+}
+
+func TestStripQuotes(t *testing.T) {
+	plain := &gmail.Part{PartID: "0", MimeType: "text/plain", Content: "Yes.\n\nOn Mon, Alice wrote:\n> Lunch?"}
+	rich := &gmail.Part{PartID: "1", MimeType: "text/html", Content: `<p>Yes.</p><div class="gmail_quote">On Mon, Alice wrote:<blockquote>Lunch?</blockquote></div>`}
+	msg := &gmail.Message{
+		Parts: []*gmail.Part{{MimeType: "multipart/alternative", Parts: []*gmail.Part{plain, rich}}},
+	}
+
+	stripQuotes(msg, "auto")
+	assert.Equal(t, "Yes.", plain.Content)
+	assert.NotContains(t, rich.Content, "Lunch")
+	assert.Equal(t, "On Mon, Alice wrote:\n> Lunch?", msg.StrippedText)
+
+	applyBodyMode(msg, "text")
+	assert.Equal(t, "Yes.", msg.Body)
+
+	t.Run("strips the unrendered body", func(t *testing.T) {
+		msg := &gmail.Message{
+			Body:         `<p>Yes.</p><div class="gmail_quote">On Mon, Alice wrote:<blockquote>Lunch?</blockquote></div>`,
+			BodyMimeType: "text/html",
+			Parts:        []*gmail.Part{{MimeType: "text/html", Content: `<p>Yes.</p><div class="gmail_quote">On Mon, Alice wrote:<blockquote>Lunch?</blockquote></div>`}},
+		}
+		stripQuotes(msg, "auto")
+		assert.Contains(t, msg.Body, "<p>Yes.</p>")
+		assert.NotContains(t, msg.Body, "Lunch")
+	})
+
+	t.Run("emptied body", func(t *testing.T) {
+		msg := &gmail.Message{
+			Body:  "> only quotes",
+			Parts: []*gmail.Part{{MimeType: "text/plain", Content: "> only quotes"}},
+		}
+		stripQuotes(msg, "auto")
+		applyBodyMode(msg, "auto")
+		assert.Empty(t, msg.Body)
+		assert.Equal(t, "> only quotes", msg.StrippedText)
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+)
+
+var (
+	dateFormat   string
+	dateTimezone string
+
+	// displayLocation is the timezone used to render dates in text output
+	displayLocation = time.Local
+)
+
+// namedDateFormats maps --date-format names to Go time layouts
+var namedDateFormats = map[string]string{
+	"rfc1123": time.RFC1123Z,
+	"rfc3339": time.RFC3339,
+	"iso":     "2006-01-02 15:04",
+}
+
+// loadDisplayLocation resolves the --tz flag to a time.Location
+func loadDisplayLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// formatMessageDate renders a message date for text output according to
+// --date-format and --tz. Falls back to the raw header if no date is known.
+func formatMessageDate(msg *gmail.Message) string {
+	if dateFormat == "raw" || msg.DateParsed == nil {
+		return msg.Date
+	}
+	return formatDate(*msg.DateParsed, dateFormat, displayLocation, time.Now())
+}
+
+// formatTime renders a timestamp that has no raw header form, such as a
+// Received hop, according to --date-format and --tz
+func formatTime(t time.Time, now time.Time) string {
+	format := dateFormat
+	if format == "" || format == "raw" {
+		format = "rfc1123"
+	}
+	return formatDate(t, format, displayLocation, now)
+}
+
+// formatDate renders t in loc using a named format, "relative", or a Go layout
+func formatDate(t time.Time, format string, loc *time.Location, now time.Time) string {
+	if format == "relative" {
+		return formatRelative(t, now)
+	}
+	layout, ok := namedDateFormats[strings.ToLower(format)]
+	if !ok {
+		layout = format
+	}
+	return t.In(loc).Format(layout)
+}
+
+// formatRelative renders the distance between t and now, e.g. "3h ago"
+func formatRelative(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := " ago"
+	if d < 0 {
+		d = -d
+		suffix = ""
+	}
+
+	var s string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		s = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d < 365*24*time.Hour:
+		s = fmt.Sprintf("%dmo", int(d/(30*24*time.Hour)))
+	default:
+		s = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+
+	if suffix == "" {
+		return "in " + s
+	}
+	return s + suffix
+}
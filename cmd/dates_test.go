@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDisplayLocation(t *testing.T) {
+	t.Run("defaults to local", func(t *testing.T) {
+		loc, err := loadDisplayLocation("")
+		require.NoError(t, err)
+		assert.Equal(t, time.Local, loc)
+
+		loc, err = loadDisplayLocation("local")
+		require.NoError(t, err)
+		assert.Equal(t, time.Local, loc)
+	})
+
+	t.Run("accepts UTC in any case", func(t *testing.T) {
+		loc, err := loadDisplayLocation("utc")
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, loc)
+	})
+
+	t.Run("rejects unknown zone", func(t *testing.T) {
+		_, err := loadDisplayLocation("Mars/Olympus_Mons")
+		assert.Error(t, err)
+	})
+}
+
+func TestFormatDate(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	plus2 := time.FixedZone("PLUS2", 2*60*60)
+
+	tests := []struct {
+		name     string
+		format   string
+		loc      *time.Location
+		expected string
+	}{
+		{"rfc1123 in UTC", "rfc1123", time.UTC, "Mon, 01 Jan 2024 12:00:00 +0000"},
+		{"rfc3339 in offset zone", "rfc3339", plus2, "2024-01-01T14:00:00+02:00"},
+		{"iso", "iso", time.UTC, "2024-01-01 12:00"},
+		{"named format is case-insensitive", "RFC3339", time.UTC, "2024-01-01T12:00:00Z"},
+		{"custom layout", "02/01/2006 15h", plus2, "01/01/2024 14h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatDate(ts, tt.format, tt.loc, ts))
+		})
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		offset   time.Duration
+		expected string
+	}{
+		{-10 * time.Second, "just now"},
+		{-5 * time.Minute, "5m ago"},
+		{-3 * time.Hour, "3h ago"},
+		{-49 * time.Hour, "2d ago"},
+		{-60 * 24 * time.Hour, "2mo ago"},
+		{-800 * 24 * time.Hour, "2y ago"},
+		{2 * time.Hour, "in 2h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatRelative(now.Add(tt.offset), now))
+		})
+	}
+}
+
+func TestFormatMessageDate(t *testing.T) {
+	oldFormat, oldLoc := dateFormat, displayLocation
+	defer func() { dateFormat, displayLocation = oldFormat, oldLoc }()
+	displayLocation = time.UTC
+
+	msg := &gmail.Message{
+		Date:       "Mon, 1 Jan 2024 07:00:00 -0500",
+		DateParsed: timePtr(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	dateFormat = "rfc3339"
+	assert.Equal(t, "2024-01-01T12:00:00Z", formatMessageDate(msg))
+
+	dateFormat = "raw"
+	assert.Equal(t, msg.Date, formatMessageDate(msg))
+
+	dateFormat = "rfc3339"
+	assert.Equal(t, "garbled", formatMessageDate(&gmail.Message{Date: "garbled"}))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+)
+
+// addressFields maps header fields that accept a .name or .email suffix in
+// --fields to the JSON key holding their parsed addresses
+var addressFields = map[string]string{
+	"from":    "fromAddresses",
+	"to":      "toAddresses",
+	"cc":      "ccAddresses",
+	"bcc":     "bccAddresses",
+	"replyto": "replyToAddresses",
+}
+
+// messageFieldKeys maps lowercased JSON keys of gmail.Message to their canonical form
+var messageFieldKeys = func() map[string]string {
+	keys := make(map[string]string)
+	t := reflect.TypeOf(gmail.Message{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[strings.ToLower(name)] = name
+		}
+	}
+	return keys
+}()
+
+// validateFields checks that every --fields path refers to a known message field
+func validateFields(fields []string) error {
+	for _, path := range fields {
+		head, sub, nested := strings.Cut(strings.ToLower(path), ".")
+		if nested {
+			if _, ok := addressFields[head]; !ok || (sub != "name" && sub != "email") {
+				return fmt.Errorf("unknown field %q: only from, to, cc, bcc and replyTo support .name and .email", path)
+			}
+			continue
+		}
+		if _, ok := messageFieldKeys[head]; !ok {
+			return fmt.Errorf("unknown field %q", path)
+		}
+	}
+	return nil
+}
+
+// selectMessageFields projects a message onto the requested field paths.
+// Paths are JSON keys of the message (e.g. "subject", "threadId") or an
+// address field with a .name or .email suffix (e.g. "from.email"). The
+// sender resolves to a single value; other address fields resolve to lists.
+func selectMessageFields(msg *gmail.Message, fields []string) (map[string]any, error) {
+	if err := validateFields(fields); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]any, len(fields))
+	for _, path := range fields {
+		head, sub, nested := strings.Cut(strings.ToLower(path), ".")
+		if !nested {
+			selected[path] = raw[messageFieldKeys[head]]
+			continue
+		}
+
+		values := []any{}
+		list, _ := raw[addressFields[head]].([]any)
+		for _, item := range list {
+			if addr, ok := item.(map[string]any); ok {
+				v, _ := addr[sub].(string)
+				values = append(values, v)
+			}
+		}
+
+		if head == "from" {
+			var first any
+			if len(values) > 0 {
+				first = values[0]
+			}
+			selected[path] = first
+		} else {
+			selected[path] = values
+		}
+	}
+
+	return selected, nil
+}
+
+// printMessageFields writes the selected fields of each message, either as
+// JSON (an object for a single message, otherwise an array) or as
+// tab-separated text with one message per line
+func printMessageFields(messages []*gmail.Message, fields []string, asJSON, single bool) error {
+	rows := make([]map[string]any, 0, len(messages))
+	for _, msg := range messages {
+		row, err := selectMessageFields(msg, fields)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	if asJSON {
+		if single && len(rows) == 1 {
+			return printJSON(rows[0])
+		}
+		return printJSON(rows)
+	}
+
+	for _, row := range rows {
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = formatFieldValue(row[f])
+		}
+		fmt.Println(strings.Join(values, "\t"))
+	}
+	return nil
+}
+
+// formatFieldValue renders a selected field for tab-separated text output
+func formatFieldValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []any:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = formatFieldValue(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
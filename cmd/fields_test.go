@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFields(t *testing.T) {
+	valid := [][]string{
+		nil,
+		{"id", "subject", "threadId"},
+		{"THREADID"},
+		{"from.email", "to.name", "cc.email", "replyTo.email"},
+	}
+	for _, fields := range valid {
+		assert.NoError(t, validateFields(fields), "%v", fields)
+	}
+
+	invalid := [][]string{
+		{"nope"},
+		{"subject.email"},
+		{"from.address"},
+	}
+	for _, fields := range invalid {
+		assert.Error(t, validateFields(fields), "%v", fields)
+	}
+}
+
+func TestSelectMessageFields(t *testing.T) {
+	msg := &gmail.Message{
+		ID:            "msg123",
+		Subject:       "Hello",
+		From:          `"Alice" <alice@example.com>`,
+		FromAddresses: []gmail.Address{{Name: "Alice", Email: "alice@example.com"}},
+		ToAddresses: []gmail.Address{
+			{Email: "bob@example.com"},
+			{Name: "Carol", Email: "carol@example.com"},
+		},
+		Labels: []string{"Work"},
+	}
+
+	t.Run("selects top-level fields", func(t *testing.T) {
+		row, err := selectMessageFields(msg, []string{"id", "Subject", "labels"})
+		require.NoError(t, err)
+		assert.Equal(t, "msg123", row["id"])
+		assert.Equal(t, "Hello", row["Subject"])
+		assert.Equal(t, []any{"Work"}, row["labels"])
+	})
+
+	t.Run("sender resolves to a single value", func(t *testing.T) {
+		row, err := selectMessageFields(msg, []string{"from.email", "from.name"})
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", row["from.email"])
+		assert.Equal(t, "Alice", row["from.name"])
+	})
+
+	t.Run("recipients resolve to lists", func(t *testing.T) {
+		row, err := selectMessageFields(msg, []string{"to.email", "cc.email"})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"bob@example.com", "carol@example.com"}, row["to.email"])
+		assert.Equal(t, []any{}, row["cc.email"])
+	})
+
+	t.Run("missing sender is nil", func(t *testing.T) {
+		row, err := selectMessageFields(&gmail.Message{ID: "x"}, []string{"from.email"})
+		require.NoError(t, err)
+		assert.Nil(t, row["from.email"])
+	})
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	assert.Equal(t, "", formatFieldValue(nil))
+	assert.Equal(t, "text", formatFieldValue("text"))
+	assert.Equal(t, "a, b", formatFieldValue([]any{"a", "b"}))
+	assert.Equal(t, "42", formatFieldValue(float64(42)))
+	assert.Equal(t, `{"email":"a@x"}`, formatFieldValue(map[string]any{"email": "a@x"}))
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFilenameLength keeps sanitized names well below the common 255 byte
+// limit, leaving room for conflict suffixes and temporary file names
+const maxFilenameLength = 200
+
+// reservedNames cannot be used as file names on Windows, with or without
+// an extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFilename makes a name from an email safe to use as a single path
+// component on any platform. Path separators and characters Windows does
+// not allow become '_', control characters are removed, trailing dots and
+// spaces are trimmed, reserved device names get a '_' prefix, and long
+// names are shortened keeping their extension. An empty result is "_".
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError || unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`/\<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+
+	stem, _, _ := strings.Cut(name, ".")
+	if reservedNames[strings.ToUpper(strings.TrimSpace(stem))] {
+		name = "_" + name
+	}
+
+	return truncateFilename(name, maxFilenameLength)
+}
+
+// truncateFilename shortens name to at most n bytes without splitting a
+// UTF-8 sequence, keeping a short extension intact
+func truncateFilename(name string, n int) string {
+	if len(name) <= n {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > 16 {
+		ext = ""
+	}
+	stem := name[:len(name)-len(ext)]
+	cut := n - len(ext)
+	for cut > 0 && !utf8.RuneStart(stem[cut]) {
+		cut--
+	}
+	return strings.TrimRight(stem[:cut], ". ") + ext
+}
+
+// conflictModes are the accepted values of --on-conflict
+var conflictModes = []string{"skip", "overwrite", "rename", "fail"}
+
+func validateConflictMode(mode string) error {
+	for _, m := range conflictModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --on-conflict %q: must be one of %s", mode, strings.Join(conflictModes, ", "))
+}
+
+// errSkipExisting is returned by resolveConflict when a file is skipped
+var errSkipExisting = errors.New("file already exists")
+
+// resolveConflict decides where to save a file whose intended path may
+// already exist on disk or be claimed by an earlier file of the same run,
+// according to mode. rename appends -1, -2, ... before the extension.
+// overwrite only replaces files of earlier runs: a path claimed in the same
+// run is renamed, so two downloads never write the same file.
+func resolveConflict(path, mode string, claimed map[string]bool) (string, error) {
+	exists := func(p string) bool {
+		if claimed[p] {
+			return true
+		}
+		_, err := os.Lstat(p)
+		return err == nil
+	}
+
+	if !exists(path) {
+		return path, nil
+	}
+
+	switch mode {
+	case "overwrite":
+		if !claimed[path] {
+			return path, nil
+		}
+		return numberedPath(path, func(p string) bool { return claimed[p] }), nil
+	case "skip":
+		return "", errSkipExisting
+	case "rename":
+		return numberedPath(path, exists), nil
+	}
+	return "", fmt.Errorf("file already exists: %s (use --on-conflict to skip, overwrite or rename)", path)
+}
+
+// numberedPath returns the first of path-1, path-2, ... (numbered before the
+// extension) that is not taken
+func numberedPath(path string, taken func(string) bool) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain name", "report.pdf", "report.pdf"},
+		{"unicode kept", "Rechnung März.pdf", "Rechnung März.pdf"},
+		{"path traversal", "../../etc/passwd", ".._.._etc_passwd"},
+		{"windows separators", `C:\Users\x.txt`, "C__Users_x.txt"},
+		{"windows reserved characters", `a<b>c:d"e|f?g*h.txt`, "a_b_c_d_e_f_g_h.txt"},
+		{"control characters", "in\x00voice\r\n.pdf\x7f", "invoice.pdf"},
+		{"trailing dots and spaces", " notes.txt. . ", "notes.txt"},
+		{"dot names", "..", "_"},
+		{"empty", "", "_"},
+		{"reserved device name", "CON", "_CON"},
+		{"reserved name with extension", "nul.txt", "_nul.txt"},
+		{"reserved name case", "Lpt1.log", "_Lpt1.log"},
+		{"reserved prefix only", "CONFIG.sys", "CONFIG.sys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeFilename(tt.input))
+		})
+	}
+
+	t.Run("shortens long names keeping the extension", func(t *testing.T) {
+		got := sanitizeFilename(strings.Repeat("ü", 150) + ".pdf")
+		assert.LessOrEqual(t, len(got), maxFilenameLength)
+		assert.True(t, strings.HasSuffix(got, "ü.pdf"))
+		assert.True(t, utf8.ValidString(got))
+	})
+}
+
+func TestValidateConflictMode(t *testing.T) {
+	for _, mode := range conflictModes {
+		assert.NoError(t, validateConflictMode(mode))
+	}
+	assert.Error(t, validateConflictMode("merge"))
+}
+
+func TestResolveConflict(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "image001.png")
+	require.NoError(t, os.WriteFile(existing, []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "image001-1.png"), []byte("x"), 0644))
+	fresh := filepath.Join(dir, "new.png")
+
+	t.Run("keeps free paths in every mode", func(t *testing.T) {
+		for _, mode := range conflictModes {
+			path, err := resolveConflict(fresh, mode, map[string]bool{})
+			require.NoError(t, err)
+			assert.Equal(t, fresh, path)
+		}
+	})
+
+	t.Run("rename picks the next free number", func(t *testing.T) {
+		path, err := resolveConflict(existing, "rename", map[string]bool{})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "image001-2.png"), path)
+	})
+
+	t.Run("rename avoids paths claimed in the same run", func(t *testing.T) {
+		claimed := map[string]bool{fresh: true}
+		path, err := resolveConflict(fresh, "rename", claimed)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "new-1.png"), path)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		_, err := resolveConflict(existing, "skip", map[string]bool{})
+		assert.ErrorIs(t, err, errSkipExisting)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		path, err := resolveConflict(existing, "overwrite", map[string]bool{})
+		require.NoError(t, err)
+		assert.Equal(t, existing, path)
+	})
+
+	t.Run("overwrite renames paths claimed in the same run", func(t *testing.T) {
+		claimed := map[string]bool{existing: true}
+		path, err := resolveConflict(existing, "overwrite", claimed)
+		require.NoError(t, err)
+		// image001-1.png exists from an earlier run and is overwritten
+		assert.Equal(t, filepath.Join(dir, "image001-1.png"), path)
+
+		claimed[path] = true
+		path, err = resolveConflict(existing, "overwrite", claimed)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "image001-2.png"), path)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		_, err := resolveConflict(existing, "fail", map[string]bool{})
+		assert.ErrorContains(t, err, "file already exists")
+	})
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/spf13/cobra"
+)
+
+var (
+	headersJSONOutput bool
+	headersGrep       string
+	headersParts      bool
+)
+
+func init() {
+	rootCmd.AddCommand(headersCmd)
+	headersCmd.Flags().BoolVarP(&headersJSONOutput, "json", "j", false, "Output as JSON")
+	headersCmd.Flags().StringVarP(&headersGrep, "grep", "g", "",
+		"Only show headers whose name matches this regular expression (case-insensitive)")
+	headersCmd.Flags().BoolVar(&headersParts, "parts", false, "Include MIME headers of nested message parts")
+}
+
+var headersCmd = &cobra.Command{
+	Use:   "headers <message-id>",
+	Short: "Show all headers of a message",
+	Long: `Show every header of a Gmail message in its original order.
+
+Useful for debugging deliverability: Received chains, authentication results
+and list headers are all shown exactly as Gmail stored them.
+
+Use --parts to also show the MIME headers of each nested part, identified by
+the same part IDs used by the attachments commands.
+
+Examples:
+  gmro headers 18abc123def456
+  gmro headers 18abc123def456 --grep '^(received|dkim-signature)$'
+  gmro headers 18abc123def456 --grep authentication --json
+  gmro headers 18abc123def456 --parts`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter *regexp.Regexp
+		if headersGrep != "" {
+			re, err := regexp.Compile("(?i)" + headersGrep)
+			if err != nil {
+				return fmt.Errorf("invalid --grep pattern: %w", err)
+			}
+			filter = re
+		}
+
+		client, err := newGmailClient()
+		if err != nil {
+			return err
+		}
+
+		if headersParts {
+			parts, err := client.GetPartHeaders(args[0])
+			if err != nil {
+				return err
+			}
+			for _, p := range parts {
+				p.Headers = filterHeaders(p.Headers, filter)
+			}
+
+			if headersJSONOutput {
+				return printJSON(parts)
+			}
+
+			for i, p := range parts {
+				if i > 0 {
+					fmt.Println()
+				}
+				printPartHeading(p)
+				printHeaders(p.Headers)
+			}
+			return nil
+		}
+
+		headers, err := client.GetHeaders(args[0])
+		if err != nil {
+			return err
+		}
+		headers = filterHeaders(headers, filter)
+
+		if headersJSONOutput {
+			return printJSON(headers)
+		}
+
+		if len(headers) == 0 {
+			fmt.Println("No matching headers found.")
+			return nil
+		}
+		printHeaders(headers)
+
+		return nil
+	},
+}
+
+// filterHeaders keeps headers whose name matches re; a nil re keeps all
+func filterHeaders(headers []gmail.Header, re *regexp.Regexp) []gmail.Header {
+	if re == nil {
+		return headers
+	}
+	filtered := []gmail.Header{}
+	for _, h := range headers {
+		if re.MatchString(h.Name) {
+			filtered = append(filtered, h)
+		}
+	}
+	return filtered
+}
+
+func printHeaders(headers []gmail.Header) {
+	for _, h := range headers {
+		fmt.Printf("%s: %s\n", h.Name, h.Value)
+	}
+}
+
+func printPartHeading(p *gmail.PartHeaders) {
+	label := "Message"
+	if p.PartID != "" {
+		label = "Part " + p.PartID
+	}
+	if p.Filename != "" {
+		fmt.Printf("=== %s (%s, %s) ===\n", label, p.MimeType, p.Filename)
+	} else {
+		fmt.Printf("=== %s (%s) ===\n", label, p.MimeType)
+	}
+}
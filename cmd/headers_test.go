@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadersCommand(t *testing.T) {
+	t.Run("has correct use", func(t *testing.T) {
+		assert.Equal(t, "headers <message-id>", headersCmd.Use)
+	})
+
+	t.Run("requires exactly one argument", func(t *testing.T) {
+		assert.Error(t, headersCmd.Args(headersCmd, []string{}))
+		assert.NoError(t, headersCmd.Args(headersCmd, []string{"msg123"}))
+		assert.Error(t, headersCmd.Args(headersCmd, []string{"msg1", "msg2"}))
+	})
+
+	t.Run("has flags", func(t *testing.T) {
+		assert.Equal(t, "j", headersCmd.Flags().Lookup("json").Shorthand)
+		assert.Equal(t, "g", headersCmd.Flags().Lookup("grep").Shorthand)
+		assert.NotNil(t, headersCmd.Flags().Lookup("parts"))
+	})
+}
+
+func TestFilterHeaders(t *testing.T) {
+	headers := []gmail.Header{
+		{Name: "Received", Value: "from b"},
+		{Name: "DKIM-Signature", Value: "v=1"},
+		{Name: "Subject", Value: "Hi"},
+		{Name: "Received", Value: "from a"},
+	}
+
+	t.Run("nil filter keeps all", func(t *testing.T) {
+		assert.Equal(t, headers, filterHeaders(headers, nil))
+	})
+
+	t.Run("matches case-insensitively and preserves order", func(t *testing.T) {
+		re := regexp.MustCompile("(?i)^(received|dkim-signature)$")
+		result := filterHeaders(headers, re)
+		assert.Equal(t, []gmail.Header{headers[0], headers[1], headers[3]}, result)
+	})
+
+	t.Run("no matches returns empty slice", func(t *testing.T) {
+		result := filterHeaders(headers, regexp.MustCompile("(?i)x-nothing"))
+		assert.NotNil(t, result)
+		assert.Empty(t, result)
+	})
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/htmlconv"
+)
+
+// outputFormats are the accepted values of --format on read and thread
+var outputFormats = []string{"text", "markdown"}
+
+// validateOutputFormat checks a --format value and that it is not combined
+// with the structured output flags
+func validateOutputFormat(format string, jsonOutput bool, fields []string) error {
+	switch format {
+	case "text":
+		return nil
+	case "markdown":
+		if jsonOutput || len(fields) > 0 {
+			return fmt.Errorf("--format markdown cannot be combined with --json or --fields")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid format %q: must be one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// markdownBodyMode returns the body mode to use with --format markdown, which
+// renders HTML as Markdown unless --body asks for something else
+func markdownBodyMode(format, body string) string {
+	if format == "markdown" && body == "auto" {
+		return "markdown"
+	}
+	return body
+}
+
+// toMarkdownBody renders an HTML part of a message as Markdown, pointing
+// inline cid: images at the attachment part that holds them
+func toMarkdownBody(msg *gmail.Message, src string) string {
+	return htmlconv.ToMarkdown(src, htmlconv.MarkdownOptions{
+		ImageRef: func(src string) string {
+			cid, ok := cutPrefixFold(src, "cid:")
+			if !ok {
+				return ""
+			}
+			if unescaped, err := url.PathUnescape(cid); err == nil {
+				cid = unescaped
+			}
+			for _, att := range msg.Attachments {
+				if att.ContentID != "" && strings.EqualFold(att.ContentID, cid) {
+					return "attachment:" + att.PartID
+				}
+			}
+			return ""
+		},
+	})
+}
+
+// printMessageMarkdown writes a message as a Markdown document with its
+// headers in a YAML front matter block
+func printMessageMarkdown(w io.Writer, msg *gmail.Message) {
+	fmt.Fprintln(w, "---")
+	writeFrontMatter(w, "id", msg.ID)
+	writeFrontMatter(w, "threadId", msg.ThreadID)
+	writeFrontMatter(w, "from", msg.From)
+	writeFrontMatter(w, "to", msg.To)
+	writeFrontMatter(w, "cc", msg.Cc)
+	writeFrontMatter(w, "subject", msg.Subject)
+	writeFrontMatter(w, "date", markdownDate(msg))
+	writeFrontMatterList(w, "labels", msg.Labels)
+	var attachments []string
+	for _, att := range msg.Attachments {
+		attachments = append(attachments, att.Filename)
+	}
+	writeFrontMatterList(w, "attachments", attachments)
+	fmt.Fprintln(w, "---")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.TrimSpace(msg.Body))
+}
+
+// printThreadMarkdown writes a thread as a single Markdown document: thread
+// headers in the front matter, then one section per message
+func printThreadMarkdown(w io.Writer, messages []*gmail.Message) {
+	first := messages[0]
+
+	var participants []string
+	seen := make(map[string]bool)
+	for _, msg := range messages {
+		for _, addr := range msg.FromAddresses {
+			if key := strings.ToLower(addr.Email); !seen[key] {
+				seen[key] = true
+				participants = append(participants, addr.Email)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "---")
+	writeFrontMatter(w, "threadId", first.ThreadID)
+	writeFrontMatter(w, "subject", first.Subject)
+	fmt.Fprintf(w, "messages: %d\n", len(messages))
+	writeFrontMatterList(w, "participants", participants)
+	writeFrontMatter(w, "firstDate", markdownDate(first))
+	writeFrontMatter(w, "lastDate", markdownDate(messages[len(messages)-1]))
+	fmt.Fprintln(w, "---")
+
+	for i, msg := range messages {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "## %d. %s\n\n", i+1, escapeMarkdownLine(msg.From))
+		fmt.Fprintf(w, "- **Date:** %s\n", escapeMarkdownLine(formatMessageDate(msg)))
+		fmt.Fprintf(w, "- **To:** %s\n", escapeMarkdownLine(msg.To))
+		if msg.Cc != "" {
+			fmt.Fprintf(w, "- **Cc:** %s\n", escapeMarkdownLine(msg.Cc))
+		}
+		if msg.Subject != first.Subject {
+			fmt.Fprintf(w, "- **Subject:** %s\n", escapeMarkdownLine(msg.Subject))
+		}
+		fmt.Fprintf(w, "- **ID:** %s\n", msg.ID)
+		if body := strings.TrimSpace(msg.Body); body != "" {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, body)
+		}
+	}
+}
+
+// markdownDate renders the message date as RFC 3339 in the display timezone
+func markdownDate(msg *gmail.Message) string {
+	if msg.DateParsed == nil {
+		return msg.Date
+	}
+	return msg.DateParsed.In(displayLocation).Format(time.RFC3339)
+}
+
+// writeFrontMatter writes a YAML key with a double-quoted string value,
+// skipping empty values
+func writeFrontMatter(w io.Writer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "%s: %s\n", key, strconv.Quote(value))
+}
+
+// writeFrontMatterList writes a YAML key with a block list of quoted values
+func writeFrontMatterList(w io.Writer, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", key)
+	for _, v := range values {
+		fmt.Fprintf(w, "  - %s\n", strconv.Quote(v))
+	}
+}
+
+// escapeMarkdownLine escapes header values such as "Name <a@b>" so that the
+// angle brackets are not read as HTML
+func escapeMarkdownLine(s string) string {
+	return strings.NewReplacer("<", `\<`, ">", `\>`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	assert.NoError(t, validateOutputFormat("text", false, nil))
+	assert.NoError(t, validateOutputFormat("text", true, nil))
+	assert.NoError(t, validateOutputFormat("markdown", false, nil))
+	assert.Error(t, validateOutputFormat("markdown", true, nil))
+	assert.Error(t, validateOutputFormat("markdown", false, []string{"id"}))
+	assert.Error(t, validateOutputFormat("html", false, nil))
+}
+
+func TestMarkdownBodyMode(t *testing.T) {
+	assert.Equal(t, "markdown", markdownBodyMode("markdown", "auto"))
+	assert.Equal(t, "text", markdownBodyMode("markdown", "text"))
+	assert.Equal(t, "auto", markdownBodyMode("text", "auto"))
+}
+
+func TestToMarkdownBody(t *testing.T) {
+	msg := &gmail.Message{
+		Attachments: []*gmail.Attachment{
+			{Filename: "logo.png", PartID: "1.2", ContentID: "logo@acme"},
+		},
+	}
+	src := `<p>Hi <b>team</b></p><img src="cid:logo%40acme" alt="logo"><img src="cid:missing" alt="x">`
+	assert.Equal(t, "Hi **team**\n\n![logo](attachment:1.2)![x](cid:missing)", toMarkdownBody(msg, src))
+}
+
+func TestPrintMessageMarkdown(t *testing.T) {
+	orig := displayLocation
+	displayLocation = time.UTC
+	defer func() { displayLocation = orig }()
+
+	msg := &gmail.Message{
+		ID:          "msg1",
+		ThreadID:    "thread1",
+		From:        "Alice <alice@example.com>",
+		To:          "bob@example.com",
+		Subject:     `Say "hi"`,
+		DateParsed:  timePtr(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)),
+		Labels:      []string{"Work"},
+		Body:        "Hello **Bob**\n",
+		Attachments: []*gmail.Attachment{{Filename: "a.pdf"}},
+	}
+
+	var buf bytes.Buffer
+	printMessageMarkdown(&buf, msg)
+	assert.Equal(t, `---
+id: "msg1"
+threadId: "thread1"
+from: "Alice <alice@example.com>"
+to: "bob@example.com"
+subject: "Say \"hi\""
+date: "2024-01-15T10:30:00Z"
+labels:
+  - "Work"
+attachments:
+  - "a.pdf"
+---
+
+Hello **Bob**
+`, buf.String())
+}
+
+func TestPrintThreadMarkdown(t *testing.T) {
+	orig, origFormat := displayLocation, dateFormat
+	displayLocation, dateFormat = time.UTC, "iso"
+	defer func() { displayLocation, dateFormat = orig, origFormat }()
+
+	messages := []*gmail.Message{
+		{
+			ID: "m1", ThreadID: "t1", Subject: "Plan", From: "Alice <alice@example.com>", To: "bob@example.com",
+			FromAddresses: []gmail.Address{{Name: "Alice", Email: "alice@example.com"}},
+			DateParsed:    timePtr(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)),
+			Body:          "First",
+		},
+		{
+			ID: "m2", ThreadID: "t1", Subject: "Re: Plan", From: "bob@example.com", To: "alice@example.com",
+			FromAddresses: []gmail.Address{{Email: "bob@example.com"}},
+			DateParsed:    timePtr(time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	var buf bytes.Buffer
+	printThreadMarkdown(&buf, messages)
+	assert.Equal(t, `---
+threadId: "t1"
+subject: "Plan"
+messages: 2
+participants:
+  - "alice@example.com"
+  - "bob@example.com"
+firstDate: "2024-01-15T10:00:00Z"
+lastDate: "2024-01-15T11:00:00Z"
+---
+
+## 1. Alice \<alice@example.com\>
+
+- **Date:** 2024-01-15 10:00
+- **To:** bob@example.com
+- **ID:** m1
+
+First
+
+## 2. bob@example.com
+
+- **Date:** 2024-01-15 11:00
+- **To:** alice@example.com
+- **Subject:** Re: Plan
+- **ID:** m2
+`, buf.String())
+}
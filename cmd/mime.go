@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/spf13/cobra"
+)
+
+var mimeJSONOutput bool
+
+func init() {
+	rootCmd.AddCommand(mimeCmd)
+	mimeCmd.Flags().BoolVarP(&mimeJSONOutput, "json", "j", false, "Output as JSON")
+}
+
+var mimeCmd = &cobra.Command{
+	Use:   "mime <message-id>",
+	Short: "Show the MIME structure of a message",
+	Long: `Show the MIME part hierarchy of a Gmail message as a tree.
+
+Each part shows its part ID (the same numbering used by the attachments
+commands), MIME type, filename, Content-Disposition, size and Content-ID.
+Parts the attachments commands treat as attachments are marked with '*',
+which helps explain why an expected attachment is missing.
+
+Examples:
+  gmro mime 18abc123def456
+  gmro mime 18abc123def456 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newGmailClient()
+		if err != nil {
+			return err
+		}
+
+		tree, err := client.GetMIMETree(args[0])
+		if err != nil {
+			return err
+		}
+
+		if mimeJSONOutput {
+			return printJSON(tree)
+		}
+
+		printMIMETree(os.Stdout, tree, "", "")
+		return nil
+	},
+}
+
+// printMIMETree writes a part and its children as an indented tree.
+// linePrefix precedes the part's own line; childPrefix precedes its children.
+func printMIMETree(w io.Writer, p *gmail.MIMEPart, linePrefix, childPrefix string) {
+	fmt.Fprintf(w, "%s%s\n", linePrefix, describeMIMEPart(p))
+
+	for i, child := range p.Parts {
+		if i == len(p.Parts)-1 {
+			printMIMETree(w, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printMIMETree(w, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// describeMIMEPart renders the one-line summary of a part
+func describeMIMEPart(p *gmail.MIMEPart) string {
+	id := p.PartID
+	if id == "" {
+		id = "(root)"
+	}
+
+	fields := []string{id, p.MimeType}
+	if p.Charset != "" {
+		fields = append(fields, "charset="+p.Charset)
+	}
+	if p.Filename != "" {
+		fields = append(fields, fmt.Sprintf("%q", p.Filename))
+	}
+	if p.Disposition != "" {
+		fields = append(fields, p.Disposition)
+	}
+	if p.ContentID != "" {
+		fields = append(fields, "cid:"+p.ContentID)
+	}
+	if len(p.Parts) == 0 {
+		fields = append(fields, formatSize(p.Size))
+	}
+	if p.IsAttachment {
+		fields = append(fields, "*")
+	}
+
+	return strings.Join(fields, "  ")
+}
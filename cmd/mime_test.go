@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMimeCommand(t *testing.T) {
+	t.Run("has correct use", func(t *testing.T) {
+		assert.Equal(t, "mime <message-id>", mimeCmd.Use)
+	})
+
+	t.Run("requires exactly one argument", func(t *testing.T) {
+		assert.Error(t, mimeCmd.Args(mimeCmd, []string{}))
+		assert.NoError(t, mimeCmd.Args(mimeCmd, []string{"msg123"}))
+	})
+
+	t.Run("has json flag", func(t *testing.T) {
+		flag := mimeCmd.Flags().Lookup("json")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "j", flag.Shorthand)
+	})
+}
+
+func TestPrintMIMETree(t *testing.T) {
+	tree := &gmail.MIMEPart{
+		MimeType: "multipart/mixed",
+		Parts: []*gmail.MIMEPart{
+			{
+				PartID:   "0",
+				MimeType: "multipart/alternative",
+				Parts: []*gmail.MIMEPart{
+					{PartID: "0.0", MimeType: "text/plain", Charset: "utf-8", Size: 120},
+					{PartID: "0.1", MimeType: "text/html", Charset: "utf-8", Size: 2048},
+				},
+			},
+			{
+				PartID: "1", MimeType: "image/png", Filename: "logo.png", Disposition: "inline",
+				ContentID: "logo@x", Size: 1536, IsAttachment: true,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	printMIMETree(&buf, tree, "", "")
+	assert.Equal(t, `(root)  multipart/mixed
+├── 0  multipart/alternative
+│   ├── 0.0  text/plain  charset=utf-8  120 B
+│   └── 0.1  text/html  charset=utf-8  2.0 KB
+└── 1  image/png  "logo.png"  inline  cid:logo@x  1.5 KB  *
+`, buf.String())
+}
@@ -24,10 +24,11 @@ func printJSON(data any) error {
 
 // MessagePrintOptions controls which fields to include in message output
 type MessagePrintOptions struct {
-	IncludeThreadID bool
-	IncludeTo       bool
-	IncludeSnippet  bool
-	IncludeBody     bool
+	IncludeThreadID  bool
+	IncludeTo        bool
+	IncludeThreading bool
+	IncludeSnippet   bool
+	IncludeBody      bool
 }
 
 // printMessageHeader prints the common header fields of a message
@@ -39,9 +40,29 @@ func printMessageHeader(msg *gmail.Message, opts MessagePrintOptions) {
 	fmt.Printf("From: %s\n", msg.From)
 	if opts.IncludeTo {
 		fmt.Printf("To: %s\n", msg.To)
+		if msg.Cc != "" {
+			fmt.Printf("Cc: %s\n", msg.Cc)
+		}
+		if msg.Bcc != "" {
+			fmt.Printf("Bcc: %s\n", msg.Bcc)
+		}
+	}
+	if opts.IncludeThreading && msg.ReplyTo != "" {
+		fmt.Printf("Reply-To: %s\n", msg.ReplyTo)
 	}
 	fmt.Printf("Subject: %s\n", msg.Subject)
-	fmt.Printf("Date: %s\n", msg.Date)
+	fmt.Printf("Date: %s\n", formatMessageDate(msg))
+	if opts.IncludeThreading {
+		if msg.MessageID != "" {
+			fmt.Printf("Message-ID: %s\n", msg.MessageID)
+		}
+		if msg.InReplyTo != "" {
+			fmt.Printf("In-Reply-To: %s\n", msg.InReplyTo)
+		}
+		if len(msg.References) > 0 {
+			fmt.Printf("References: %s\n", strings.Join(msg.References, " "))
+		}
+	}
 	if len(msg.Labels) > 0 {
 		fmt.Printf("Labels: %s\n", strings.Join(msg.Labels, ", "))
 	}
@@ -11,6 +11,7 @@ func TestMessagePrintOptions(t *testing.T) {
 		opts := MessagePrintOptions{}
 		assert.False(t, opts.IncludeThreadID)
 		assert.False(t, opts.IncludeTo)
+		assert.False(t, opts.IncludeThreading)
 		assert.False(t, opts.IncludeSnippet)
 		assert.False(t, opts.IncludeBody)
 	})
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	progressBarWidth = 30
+	progressInterval = 100 * time.Millisecond
+)
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressWriter passes writes through to w while drawing a progress bar
+// for a download of total bytes on out. A total of zero shows only the
+// number of bytes written.
+type progressWriter struct {
+	w       io.Writer
+	out     io.Writer
+	name    string
+	total   int64
+	written int64
+	drawn   time.Time
+	now     func() time.Time
+}
+
+func newProgressWriter(w, out io.Writer, name string, total int64) *progressWriter {
+	return &progressWriter{w: w, out: out, name: name, total: total, now: time.Now}
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if now := p.now(); now.Sub(p.drawn) >= progressInterval {
+		p.drawn = now
+		p.draw()
+	}
+	return n, err
+}
+
+func (p *progressWriter) draw() {
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "\r%s  %s\033[K", p.name, formatSize(p.written))
+		return
+	}
+
+	frac := min(float64(p.written)/float64(p.total), 1)
+	filled := int(frac * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	if filled > 0 && filled < progressBarWidth {
+		bar = bar[:filled-1] + ">" + bar[filled:]
+	}
+	fmt.Fprintf(p.out, "\r%s [%s] %3.0f%%  %s / %s\033[K",
+		p.name, bar, frac*100, formatSize(p.written), formatSize(p.total))
+}
+
+// Finish clears the progress bar so the next line starts clean
+func (p *progressWriter) Finish() {
+	if !p.drawn.IsZero() {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+}
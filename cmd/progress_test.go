@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressWriter(t *testing.T) {
+	// clock advances past the redraw interval on every write
+	clock := func() func() time.Time {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		return func() time.Time {
+			now = now.Add(progressInterval)
+			return now
+		}
+	}
+
+	t.Run("draws a bar for a known size", func(t *testing.T) {
+		var dst, out bytes.Buffer
+		p := newProgressWriter(&dst, &out, "report.pdf", 2048)
+		p.now = clock()
+
+		n, err := p.Write(make([]byte, 1024))
+		assert.NoError(t, err)
+		assert.Equal(t, 1024, n)
+		assert.Equal(t, 1024, dst.Len())
+		assert.Equal(t, "\rreport.pdf [==============>               ]  50%  1.0 KB / 2.0 KB\033[K", out.String())
+
+		out.Reset()
+		p.Finish()
+		assert.Equal(t, "\r\033[K", out.String())
+	})
+
+	t.Run("shows bytes for an unknown size", func(t *testing.T) {
+		var dst, out bytes.Buffer
+		p := newProgressWriter(&dst, &out, "data.bin", 0)
+		p.now = clock()
+
+		_, _ = p.Write(make([]byte, 10))
+		assert.Equal(t, "\rdata.bin  10 B\033[K", out.String())
+	})
+
+	t.Run("throttles redraws", func(t *testing.T) {
+		var dst, out bytes.Buffer
+		p := newProgressWriter(&dst, &out, "a", 100)
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		p.now = func() time.Time { return now }
+
+		_, _ = p.Write([]byte("x"))
+		drawn := out.Len()
+		_, _ = p.Write([]byte("y"))
+		assert.Equal(t, drawn, out.Len())
+		assert.Equal(t, 2, dst.Len())
+	})
+
+	t.Run("finish is silent when nothing was drawn", func(t *testing.T) {
+		var out bytes.Buffer
+		newProgressWriter(&bytes.Buffer{}, &out, "a", 100).Finish()
+		assert.Empty(t, out.String())
+	})
+}
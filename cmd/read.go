@@ -1,47 +1,147 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/spf13/cobra"
 )
 
-var readJSONOutput bool
+var (
+	readJSONOutput bool
+	readFields     []string
+	readBody       string
+	readFormat     string
+	readWorkers    int
+)
 
 func init() {
 	rootCmd.AddCommand(readCmd)
 	readCmd.Flags().BoolVarP(&readJSONOutput, "json", "j", false, "Output result as JSON")
+	readCmd.Flags().StringSliceVar(&readFields, "fields", nil,
+		"Only output these fields, e.g. subject,from.email,to.email")
+	readCmd.Flags().StringVar(&readBody, "body", "auto", bodyFlagUsage)
+	readCmd.Flags().StringVar(&readFormat, "format", "text",
+		"Output format: text|markdown (markdown puts the headers in a front matter block)")
+	readCmd.Flags().IntVar(&readWorkers, "concurrency", defaultConcurrency,
+		"Number of messages to fetch at the same time")
 }
 
 var readCmd = &cobra.Command{
-	Use:   "read <message-id>",
-	Short: "Read a single message",
-	Long: `Read the full content of a Gmail message by its ID.
+	Use:   "read <message-id>...",
+	Short: "Read one or more messages",
+	Long: `Read the full content of Gmail messages by their IDs.
+
+The message IDs can be obtained from the search command output. Pass "-"
+to read newline-separated IDs from stdin, e.g. from 'gmro search --ids-only'.
+Messages are fetched concurrently (see --concurrency) and printed in the
+order given. With several IDs, JSON output is an array; IDs that cannot be
+fetched are reported on stderr without stopping the rest of the batch, and
+the command exits non-zero.
+
+HTML-only messages are rendered as plain text, with link targets listed as
+numbered footnotes. Use --body html for the raw HTML part, or --body text to
+render the HTML part even when the message has a plain text alternative.
+--body markdown converts the HTML part to Markdown, with inline images
+referenced as attachment:<part-id>. Rendering applies to text and Markdown
+output only; --json and --fields return the body as sent.
 
-The message ID can be obtained from the search command output.
+--format markdown prints the message as a Markdown document with the
+headers in a YAML front matter block, ready to paste into a ticket or wiki.
 
 Examples:
   gmro read 18abc123def456
-  gmro read 18abc123def456 --json`,
-	Args: cobra.ExactArgs(1),
+  gmro read 18abc123def456 --json
+  gmro read 18abc123def456 --body html
+  gmro read 18abc123def456 --format markdown > message.md
+  gmro read 18abc123def456 --fields subject,from.email --json
+  gmro read 18abc123def456 18abc123def789 --json
+  gmro search "is:unread" --ids-only | gmro read -`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := newGmailClient()
-		if err != nil {
+		if err := validateFields(readFields); err != nil {
+			return err
+		}
+		if err := validateBodyMode(readBody); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(readFormat, readJSONOutput, readFields); err != nil {
 			return err
 		}
 
-		msg, err := client.GetMessage(args[0], true)
+		if err := validateConcurrency(readWorkers); err != nil {
+			return err
+		}
+		ids, err := collectIDs(args, os.Stdin)
 		if err != nil {
 			return err
 		}
 
-		if readJSONOutput {
-			return printJSON(msg)
+		client, err := newGmailClient()
+		if err != nil {
+			return err
 		}
 
-		printMessageHeader(msg, MessagePrintOptions{
-			IncludeTo:   true,
-			IncludeBody: true,
+		results := fetchAll(ids, readWorkers, func(id string) (*gmail.Message, error) {
+			return client.GetMessage(id, true)
 		})
+		if len(ids) == 1 && results[0].Err != nil {
+			return results[0].Err
+		}
+		failed := reportFetchErrors(os.Stderr, results)
 
-		return nil
+		messages := make([]*gmail.Message, 0, len(results))
+		for _, r := range results {
+			if r.Err == nil {
+				messages = append(messages, r.Value)
+			}
+		}
+		if err := printMessages(messages, len(ids) > 1); err != nil {
+			return err
+		}
+		if failed > 0 {
+			cmd.SilenceUsage = true
+		}
+		return batchError(failed, len(ids))
 	},
 }
+
+// printMessages prints the fetched messages as fields, JSON, text or Markdown
+func printMessages(messages []*gmail.Message, batch bool) error {
+	if len(readFields) > 0 {
+		return printMessageFields(messages, readFields, readJSONOutput, !batch)
+	}
+	if readJSONOutput {
+		return printBatchJSON(messages, batch)
+	}
+
+	for i, msg := range messages {
+		if i > 0 {
+			// Markdown documents are separated by a blank line, text by a rule
+			if readFormat == "markdown" {
+				fmt.Println()
+			} else {
+				fmt.Println("---")
+			}
+		}
+		printMessage(msg)
+	}
+	return nil
+}
+
+// printMessage prints one message in the --format chosen for read. The body
+// is rendered here rather than before JSON output, which keeps the original
+// body.
+func printMessage(msg *gmail.Message) {
+	applyBodyMode(msg, markdownBodyMode(readFormat, readBody))
+	if readFormat == "markdown" {
+		printMessageMarkdown(os.Stdout, msg)
+		return
+	}
+	printMessageHeader(msg, MessagePrintOptions{
+		IncludeTo:        true,
+		IncludeThreading: true,
+		IncludeBody:      true,
+	})
+}
@@ -1,17 +1,22 @@
 package cmd
 
 import (
+	"encoding/json"
+	"io"
+	"os"
 	"testing"
 
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadCommand(t *testing.T) {
 	t.Run("has correct use", func(t *testing.T) {
-		assert.Equal(t, "read <message-id>", readCmd.Use)
+		assert.Equal(t, "read <message-id>...", readCmd.Use)
 	})
 
-	t.Run("requires exactly one argument", func(t *testing.T) {
+	t.Run("requires at least one argument", func(t *testing.T) {
 		err := readCmd.Args(readCmd, []string{})
 		assert.Error(t, err)
 
@@ -19,7 +24,7 @@ func TestReadCommand(t *testing.T) {
 		assert.NoError(t, err)
 
 		err = readCmd.Args(readCmd, []string{"msg1", "msg2"})
-		assert.Error(t, err)
+		assert.NoError(t, err)
 	})
 
 	t.Run("has json flag", func(t *testing.T) {
@@ -37,4 +42,72 @@ func TestReadCommand(t *testing.T) {
 	t.Run("long description mentions message ID source", func(t *testing.T) {
 		assert.Contains(t, readCmd.Long, "search")
 	})
+
+	t.Run("has fields flag", func(t *testing.T) {
+		assert.NotNil(t, readCmd.Flags().Lookup("fields"))
+	})
+
+	t.Run("has body flag", func(t *testing.T) {
+		flag := readCmd.Flags().Lookup("body")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "auto", flag.DefValue)
+	})
+
+	t.Run("has format flag", func(t *testing.T) {
+		flag := readCmd.Flags().Lookup("format")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "text", flag.DefValue)
+	})
+
+	t.Run("has concurrency flag", func(t *testing.T) {
+		flag := readCmd.Flags().Lookup("concurrency")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "4", flag.DefValue)
+	})
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+
+	fn()
+	require.NoError(t, w.Close())
+	return <-done
+}
+
+// htmlOnlyMessage returns a message whose only body part is HTML
+func htmlOnlyMessage() *gmail.Message {
+	const body = "<p>Hello <b>world</b></p>"
+	return &gmail.Message{
+		ID:           "msg123",
+		Body:         body,
+		BodyMimeType: "text/html",
+		Parts:        []*gmail.Part{{PartID: "0", MimeType: "text/html", Content: body}},
+	}
+}
+
+func TestPrintMessagesJSONKeepsBody(t *testing.T) {
+	readJSONOutput = true
+	defer func() { readJSONOutput = false }()
+
+	out := captureStdout(t, func() {
+		require.NoError(t, printMessages([]*gmail.Message{htmlOnlyMessage()}, false))
+	})
+
+	var msg gmail.Message
+	require.NoError(t, json.Unmarshal([]byte(out), &msg))
+	assert.Equal(t, "<p>Hello <b>world</b></p>", msg.Body)
+	assert.Equal(t, "text/html", msg.BodyMimeType)
 }
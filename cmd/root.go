@@ -21,6 +21,14 @@ It provides read-only access to your Gmail account, allowing you to:
 
 This tool uses OAuth2 for authentication and only requests read-only
 permissions (gmail.readonly scope).`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		loc, err := loadDisplayLocation(dateTimezone)
+		if err != nil {
+			return err
+		}
+		displayLocation = loc
+		return nil
+	},
 }
 
 func Execute() {
@@ -32,6 +40,10 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.PersistentFlags().StringVar(&dateTimezone, "tz", "local",
+		"Timezone for dates in text output (IANA name, 'local' or 'UTC')")
+	rootCmd.PersistentFlags().StringVar(&dateFormat, "date-format", "rfc1123",
+		"Date format for text output: rfc1123, rfc3339, iso, relative, raw, or a Go time layout")
 }
 
 var versionCmd = &cobra.Command{
@@ -60,3 +60,15 @@ func TestVersionCommand(t *testing.T) {
 		assert.Equal(t, "version", versionCmd.Use)
 	})
 }
+
+func TestRootPersistentFlags(t *testing.T) {
+	t.Run("has date flags", func(t *testing.T) {
+		tz := rootCmd.PersistentFlags().Lookup("tz")
+		assert.NotNil(t, tz)
+		assert.Equal(t, "local", tz.DefValue)
+
+		format := rootCmd.PersistentFlags().Lookup("date-format")
+		assert.NotNil(t, format)
+		assert.Equal(t, "rfc1123", format.DefValue)
+	})
+}
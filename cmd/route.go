@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/received"
+	"github.com/spf13/cobra"
+)
+
+var routeJSONOutput bool
+
+func init() {
+	rootCmd.AddCommand(routeCmd)
+	routeCmd.Flags().BoolVarP(&routeJSONOutput, "json", "j", false, "Output as JSON")
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route <message-id>",
+	Short: "Show the delivery path of a message",
+	Long: `Trace the servers a Gmail message passed through on its way to you.
+
+Parses the Received header chain into hops ordered from the origin to the
+final recipient, with the sending and receiving host, the client IP, the
+protocol and the timestamp of each hop, plus the delay since the previous
+hop.
+
+Anomalies are flagged per hop: timestamps that go backwards, delays longer
+than an hour, private or reserved IP addresses and timestamps that could not
+be parsed. Received headers below Gmail's own are added by the sending side
+and can be forged.
+
+Examples:
+  gmro route 18abc123def456
+  gmro route 18abc123def456 --tz UTC
+  gmro route 18abc123def456 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newGmailClient()
+		if err != nil {
+			return err
+		}
+
+		headers, err := client.GetHeaders(args[0])
+		if err != nil {
+			return err
+		}
+
+		route := received.Analyze(gmail.FindHeaders(headers, "Received"))
+
+		if routeJSONOutput {
+			return printJSON(route)
+		}
+
+		if len(route.Hops) == 0 {
+			fmt.Println("No Received headers found.")
+			return nil
+		}
+
+		printRoute(route, time.Now())
+		return nil
+	},
+}
+
+func printRoute(route *received.Route, now time.Time) {
+	for _, hop := range route.Hops {
+		fmt.Printf("Hop %d", hop.Index)
+		if d, ok := hop.Delay(); ok {
+			fmt.Printf("  (%s)", formatHopDelay(d))
+		}
+		fmt.Println()
+
+		if hop.Timestamp != nil {
+			fmt.Printf("  Time:  %s\n", formatTime(*hop.Timestamp, now))
+		}
+		if from := formatHopFrom(hop); from != "" {
+			fmt.Printf("  From:  %s\n", from)
+		}
+		if hop.By != "" {
+			fmt.Printf("  By:    %s\n", hop.By)
+		}
+		if hop.With != "" {
+			fmt.Printf("  With:  %s\n", hop.With)
+		}
+		for _, a := range hop.Anomalies {
+			fmt.Printf("  ! %s\n", a)
+		}
+		fmt.Println()
+	}
+
+	total := time.Duration(route.TotalDelaySeconds * float64(time.Second)).Round(time.Second)
+	fmt.Printf("Total: %d hop(s) in %s\n", len(route.Hops), total)
+	if len(route.Anomalies) > 0 {
+		fmt.Printf("Anomalies: %d\n", len(route.Anomalies))
+	}
+}
+
+// formatHopFrom combines the claimed host name with the reverse DNS name and IP
+func formatHopFrom(hop received.Hop) string {
+	var extra []string
+	if hop.FromRDNS != "" && !strings.EqualFold(hop.FromRDNS, hop.From) {
+		extra = append(extra, hop.FromRDNS)
+	}
+	if hop.IP != "" {
+		extra = append(extra, "["+hop.IP+"]")
+	}
+	if len(extra) == 0 {
+		return hop.From
+	}
+	if hop.From == "" {
+		return strings.Join(extra, " ")
+	}
+	return hop.From + " (" + strings.Join(extra, " ") + ")"
+}
+
+// formatHopDelay renders a delay with second precision and an explicit sign
+func formatHopDelay(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < 0 {
+		return d.String()
+	}
+	return "+" + d.String()
+}
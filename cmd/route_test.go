@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/received"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteCommand(t *testing.T) {
+	t.Run("has correct use", func(t *testing.T) {
+		assert.Equal(t, "route <message-id>", routeCmd.Use)
+	})
+
+	t.Run("requires exactly one argument", func(t *testing.T) {
+		assert.Error(t, routeCmd.Args(routeCmd, []string{}))
+		assert.NoError(t, routeCmd.Args(routeCmd, []string{"msg123"}))
+	})
+
+	t.Run("has json flag", func(t *testing.T) {
+		flag := routeCmd.Flags().Lookup("json")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "j", flag.Shorthand)
+	})
+}
+
+func TestFormatHopFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		hop      received.Hop
+		expected string
+	}{
+		{"empty", received.Hop{}, ""},
+		{"host only", received.Hop{From: "a.example.com"}, "a.example.com"},
+		{"same rdns", received.Hop{From: "a.example.com", FromRDNS: "A.example.com", IP: "203.0.113.5"}, "a.example.com ([203.0.113.5])"},
+		{"different rdns", received.Hop{From: "laptop", FromRDNS: "host.isp.net", IP: "203.0.113.5"}, "laptop (host.isp.net [203.0.113.5])"},
+		{"ip only", received.Hop{IP: "203.0.113.5"}, "[203.0.113.5]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatHopFrom(tt.hop))
+		})
+	}
+}
+
+func TestFormatHopDelay(t *testing.T) {
+	assert.Equal(t, "+5s", formatHopDelay(5*time.Second+200*time.Millisecond))
+	assert.Equal(t, "-1m0s", formatHopDelay(-time.Minute))
+	assert.Equal(t, "+0s", formatHopDelay(0))
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/open-cli-collective/gmail-ro/internal/saved"
+	"github.com/spf13/cobra"
+)
+
+var (
+	savedAddForce  bool
+	savedListJSON  bool
+	savedRunParams []string
+)
+
+func init() {
+	rootCmd.AddCommand(savedCmd)
+	savedCmd.AddCommand(savedAddCmd)
+	savedCmd.AddCommand(savedListCmd)
+	savedCmd.AddCommand(savedRemoveCmd)
+	savedCmd.AddCommand(savedRunCmd)
+
+	savedAddCmd.Flags().BoolVar(&savedAddForce, "force", false, "Replace an existing saved search with the same name")
+	savedListCmd.Flags().BoolVarP(&savedListJSON, "json", "j", false, "Output results as JSON")
+
+	savedRunCmd.Flags().Int64VarP(&searchMaxResults, "max", "m", 10, "Maximum number of results to return")
+	savedRunCmd.Flags().BoolVarP(&searchJSONOutput, "json", "j", false, "Output results as JSON")
+	savedRunCmd.Flags().StringVar(&searchSort, "sort", "",
+		"Sort results by "+strings.Join(gmail.SortKeys, "|")+" (default: Gmail order)")
+	savedRunCmd.Flags().BoolVar(&searchReverse, "reverse", false, "Reverse the sort order")
+	savedRunCmd.Flags().StringSliceVar(&searchFields, "fields", nil,
+		"Only output these fields, e.g. id,subject,from.email")
+	savedRunCmd.Flags().StringArrayVarP(&savedRunParams, "param", "p", nil,
+		"Template parameter as key=value (repeatable)")
+}
+
+var savedCmd = &cobra.Command{
+	Use:   "saved",
+	Short: "Manage saved searches",
+	Long: `Manage named Gmail searches stored in the gmro config directory.
+
+Saved queries are Go templates, so parts of a query can be filled in at run
+time with --param. The built-in parameters {{.Today}} and {{.Yesterday}}
+expand to dates in Gmail's YYYY/MM/DD format.
+
+Saved searches can be run with 'gmro saved run <name>' or
+'gmro search @<name>'.
+
+Examples:
+  gmro saved add invoices "from:billing@ has:attachment newer_than:30d"
+  gmro saved add since "label:reports after:{{.Since}}"
+  gmro saved list
+  gmro saved run invoices
+  gmro search @since --param Since=2024/01/01
+  gmro saved remove invoices`,
+}
+
+var savedAddCmd = &cobra.Command{
+	Use:   "add <name> <query>",
+	Short: "Save a search query under a name",
+	Long: `Save a Gmail search query under a name.
+
+The query may contain template parameters such as {{.Since}}, which must be
+supplied with --param when the search is run.
+
+Examples:
+  gmro saved add invoices "from:billing@ has:attachment newer_than:30d"
+  gmro saved add since "label:reports after:{{.Since}}"
+  gmro saved add invoices "from:billing@" --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadSavedSearches()
+		if err != nil {
+			return err
+		}
+
+		if err := store.Add(args[0], args[1], savedAddForce); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved search: %s\n", args[0])
+		return nil
+	},
+}
+
+var savedListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List saved searches",
+	Long: `List all saved searches with their query templates.
+
+Examples:
+  gmro saved list
+  gmro saved list --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadSavedSearches()
+		if err != nil {
+			return err
+		}
+
+		searches := store.List()
+		if savedListJSON {
+			return printJSON(searches)
+		}
+
+		if len(searches) == 0 {
+			fmt.Println("No saved searches.")
+			return nil
+		}
+
+		fmt.Printf("%-20s %s\n", "NAME", "QUERY")
+		fmt.Println(strings.Repeat("-", 60))
+		for _, s := range searches {
+			fmt.Printf("%-20s %s\n", truncate(s.Name, 20), s.Query)
+		}
+
+		return nil
+	},
+}
+
+var savedRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a saved search",
+	Long: `Remove a saved search by name.
+
+Examples:
+  gmro saved remove invoices`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := loadSavedSearches()
+		if err != nil {
+			return err
+		}
+
+		if err := store.Remove(args[0]); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed saved search: %s\n", args[0])
+		return nil
+	},
+}
+
+var savedRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a saved search",
+	Long: `Run a saved search, expanding any template parameters.
+
+This is equivalent to 'gmro search @<name>'.
+
+Examples:
+  gmro saved run invoices
+  gmro saved run since --param Since=2024/01/01 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query, err := expandSavedSearch(args[0], savedRunParams)
+		if err != nil {
+			return err
+		}
+		return runSearch(query)
+	},
+}
+
+// loadSavedSearches opens the saved searches file in the config directory
+func loadSavedSearches() (*saved.Store, error) {
+	dir, err := gmail.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return saved.Load(filepath.Join(dir, saved.FileName))
+}
+
+// expandSavedSearch looks up a saved search and renders it with the given
+// key=value parameters
+func expandSavedSearch(name string, params []string) (string, error) {
+	values, err := saved.ParseParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	store, err := loadSavedSearches()
+	if err != nil {
+		return "", err
+	}
+
+	query, err := store.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	return saved.Expand(query, values, time.Now())
+}
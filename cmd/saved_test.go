@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedCommand(t *testing.T) {
+	t.Run("has correct use", func(t *testing.T) {
+		assert.Equal(t, "saved", savedCmd.Use)
+	})
+
+	t.Run("has subcommands", func(t *testing.T) {
+		var names []string
+		for _, cmd := range savedCmd.Commands() {
+			names = append(names, cmd.Name())
+		}
+		assert.Contains(t, names, "add")
+		assert.Contains(t, names, "list")
+		assert.Contains(t, names, "remove")
+		assert.Contains(t, names, "run")
+	})
+
+	t.Run("add requires name and query", func(t *testing.T) {
+		assert.Error(t, savedAddCmd.Args(savedAddCmd, []string{"name"}))
+		assert.NoError(t, savedAddCmd.Args(savedAddCmd, []string{"name", "query"}))
+	})
+
+	t.Run("run has search flags", func(t *testing.T) {
+		for _, name := range []string{"max", "json", "param", "sort", "reverse"} {
+			assert.NotNil(t, savedRunCmd.Flags().Lookup(name), "flag %s should exist", name)
+		}
+	})
+}
+
+func TestExpandSavedSearch(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	store, err := loadSavedSearches()
+	require.NoError(t, err)
+	require.NoError(t, store.Add("reports", "label:reports after:{{.Since}}", false))
+	require.NoError(t, store.Save())
+
+	t.Run("expands parameters", func(t *testing.T) {
+		query, err := expandSavedSearch("reports", []string{"Since=2024/01/01"})
+		require.NoError(t, err)
+		assert.Equal(t, "label:reports after:2024/01/01", query)
+	})
+
+	t.Run("errors on missing parameter", func(t *testing.T) {
+		_, err := expandSavedSearch("reports", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("errors on unknown name", func(t *testing.T) {
+		_, err := expandSavedSearch("nope", nil)
+		assert.Error(t, err)
+	})
+}
@@ -2,66 +2,189 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/spf13/cobra"
 )
 
 var (
 	searchMaxResults int64
 	searchJSONOutput bool
+	searchParams     []string
+	searchCriteria   gmail.SearchCriteria
+	searchPrintQuery bool
+	searchSort       string
+	searchReverse    bool
+	searchFields     []string
+	searchIDsOnly    bool
 )
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
-	searchCmd.Flags().Int64VarP(&searchMaxResults, "max", "m", 10, "Maximum number of results to return")
+	searchCmd.Flags().Int64VarP(&searchMaxResults, "max", "m", 10, "Maximum number of results to return (0 for no limit)")
 	searchCmd.Flags().BoolVarP(&searchJSONOutput, "json", "j", false, "Output results as JSON")
+	searchCmd.Flags().StringArrayVarP(&searchParams, "param", "p", nil,
+		"Parameter for a saved search template as key=value (repeatable)")
+
+	searchCmd.Flags().StringArrayVar(&searchCriteria.From, "from", nil, "Match sender (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchCriteria.To, "to", nil, "Match recipient (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchCriteria.Subject, "subject", nil, "Match words in the subject (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchCriteria.Labels, "label", nil, "Match label (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchCriteria.Filenames, "filename", nil, "Match attachment filename or type (repeatable)")
+	searchCmd.Flags().StringVar(&searchCriteria.After, "after", "", "Only messages after this date (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&searchCriteria.Before, "before", "", "Only messages before this date (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&searchCriteria.Larger, "larger", "", "Only messages larger than this size (e.g. 500K, 5M)")
+	searchCmd.Flags().BoolVar(&searchCriteria.HasAttachment, "has-attachment", false, "Only messages with attachments")
+	searchCmd.Flags().BoolVar(&searchCriteria.Unread, "unread", false, "Only unread messages")
+	searchCmd.Flags().StringVar(&searchSort, "sort", "",
+		"Sort results by "+strings.Join(gmail.SortKeys, "|")+" (default: Gmail order)")
+	searchCmd.Flags().BoolVar(&searchReverse, "reverse", false, "Reverse the sort order")
+	searchCmd.Flags().StringSliceVar(&searchFields, "fields", nil,
+		"Only output these fields, e.g. id,subject,from.email")
+	searchCmd.Flags().BoolVar(&searchIDsOnly, "ids-only", false,
+		"Print only message IDs, one per line (for piping into read or thread)")
+	searchCmd.Flags().BoolVar(&searchPrintQuery, "print-query", false, "Print the compiled Gmail query and exit without searching")
 }
 
 var searchCmd = &cobra.Command{
-	Use:   "search <query>",
+	Use:   "search [query]",
 	Short: "Search for messages",
 	Long: `Search for Gmail messages using Gmail's search syntax.
 
+A query starting with '@' runs the saved search with that name
+(see 'gmro saved').
+
+Structured flags such as --from, --subject and --after are compiled into
+correctly quoted Gmail operators and combined with the positional query.
+Use --print-query to see the final query string without searching.
+
+--ids-only prints one message ID per line without fetching the messages,
+ready to pipe into 'gmro read -' or 'gmro thread -'.
+
 Examples:
   gmro search "from:alice@example.com"
   gmro search "subject:meeting" --max 20
   gmro search "is:unread" --json
   gmro search "after:2024/01/01 before:2024/02/01"
+  gmro search @invoices --param Since=2024/01/01
+  gmro search --from billing@example.com --has-attachment --after 2024-01-01
+  gmro search "in:inbox" --subject "weekly report" --unread --print-query
+  gmro search "has:attachment" --sort size --reverse
+  gmro search "is:unread" --fields id,from.email,subject
+  gmro search "is:unread" --ids-only | gmro read -
 
 For more query operators, see: https://support.google.com/mail/answer/7190`,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := newGmailClient()
+		var query string
+		if len(args) > 0 {
+			query = args[0]
+		}
+		if name, ok := strings.CutPrefix(query, "@"); ok {
+			expanded, err := expandSavedSearch(name, searchParams)
+			if err != nil {
+				return err
+			}
+			query = expanded
+		}
+
+		query, err := searchCriteria.BuildQuery(query)
 		if err != nil {
 			return err
 		}
+		if query == "" {
+			return fmt.Errorf("must specify a query or at least one search flag")
+		}
+
+		if searchPrintQuery {
+			fmt.Println(query)
+			return nil
+		}
+
+		return runSearch(query)
+	},
+}
+
+// runSearch executes a Gmail query and prints the results using the search flags
+func runSearch(query string) error {
+	if searchSort != "" {
+		if err := gmail.ValidateSortKey(searchSort); err != nil {
+			return err
+		}
+	}
+	if err := validateFields(searchFields); err != nil {
+		return err
+	}
+	if searchIDsOnly && (searchJSONOutput || len(searchFields) > 0) {
+		return fmt.Errorf("--ids-only cannot be combined with --json or --fields")
+	}
+
+	client, err := newGmailClient()
+	if err != nil {
+		return err
+	}
 
-		messages, skipped, err := client.SearchMessages(args[0], searchMaxResults)
+	// Listing IDs needs no per-message requests unless results are sorted
+	if searchIDsOnly && searchSort == "" {
+		ids, err := client.ListMessageIDs(query, searchMaxResults)
 		if err != nil {
 			return err
 		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		return nil
+	}
 
-		if len(messages) == 0 {
+	messages, skipped, err := client.SearchMessages(query, searchMaxResults)
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		if !searchIDsOnly {
 			fmt.Println("No messages found.")
-			return nil
 		}
+		return nil
+	}
 
-		if searchJSONOutput {
-			return printJSON(messages)
+	if searchSort != "" {
+		if err := gmail.SortMessages(messages, searchSort, searchReverse); err != nil {
+			return err
 		}
+	}
 
+	if searchIDsOnly {
 		for _, msg := range messages {
-			printMessageHeader(msg, MessagePrintOptions{
-				IncludeThreadID: true,
-				IncludeSnippet:  true,
-			})
-			fmt.Println("---")
+			fmt.Println(msg.ID)
 		}
-
 		if skipped > 0 {
-			fmt.Printf("Note: %d message(s) could not be retrieved.\n", skipped)
+			fmt.Fprintf(os.Stderr, "Note: %d message(s) could not be retrieved.\n", skipped)
 		}
-
 		return nil
-	},
+	}
+
+	if len(searchFields) > 0 {
+		return printMessageFields(messages, searchFields, searchJSONOutput, false)
+	}
+
+	if searchJSONOutput {
+		return printJSON(messages)
+	}
+
+	for _, msg := range messages {
+		printMessageHeader(msg, MessagePrintOptions{
+			IncludeThreadID: true,
+			IncludeSnippet:  true,
+		})
+		fmt.Println("---")
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Note: %d message(s) could not be retrieved.\n", skipped)
+	}
+
+	return nil
 }
@@ -8,12 +8,12 @@ import (
 
 func TestSearchCommand(t *testing.T) {
 	t.Run("has correct use", func(t *testing.T) {
-		assert.Equal(t, "search <query>", searchCmd.Use)
+		assert.Equal(t, "search [query]", searchCmd.Use)
 	})
 
-	t.Run("requires exactly one argument", func(t *testing.T) {
+	t.Run("accepts at most one argument", func(t *testing.T) {
 		err := searchCmd.Args(searchCmd, []string{})
-		assert.Error(t, err)
+		assert.NoError(t, err)
 
 		err = searchCmd.Args(searchCmd, []string{"query"})
 		assert.NoError(t, err)
@@ -36,9 +36,30 @@ func TestSearchCommand(t *testing.T) {
 		assert.Equal(t, "false", flag.DefValue)
 	})
 
+	t.Run("has ids-only flag", func(t *testing.T) {
+		flag := searchCmd.Flags().Lookup("ids-only")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "false", flag.DefValue)
+	})
+
+	t.Run("has query builder flags", func(t *testing.T) {
+		flags := []string{
+			"from", "to", "subject", "label", "after", "before",
+			"has-attachment", "larger", "filename", "unread", "print-query",
+			"sort", "reverse",
+		}
+		for _, name := range flags {
+			assert.NotNil(t, searchCmd.Flags().Lookup(name), "flag %s should exist", name)
+		}
+	})
+
 	t.Run("has examples in long description", func(t *testing.T) {
 		assert.Contains(t, searchCmd.Long, "from:")
 		assert.Contains(t, searchCmd.Long, "subject:")
 		assert.Contains(t, searchCmd.Long, "is:unread")
 	})
+
+	t.Run("has fields flag", func(t *testing.T) {
+		assert.NotNil(t, searchCmd.Flags().Lookup("fields"))
+	})
 }
@@ -2,61 +2,232 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/spf13/cobra"
 )
 
-var threadJSONOutput bool
+var (
+	threadJSONOutput bool
+	threadFields     []string
+	threadBody       string
+	threadFormat     string
+	threadStrip      bool
+	threadTree       bool
+	threadSummary    bool
+	threadWorkers    int
+)
 
 func init() {
 	rootCmd.AddCommand(threadCmd)
 	threadCmd.Flags().BoolVarP(&threadJSONOutput, "json", "j", false, "Output result as JSON")
+	threadCmd.Flags().StringSliceVar(&threadFields, "fields", nil,
+		"Only output these fields, e.g. id,date,from.email")
+	threadCmd.Flags().StringVar(&threadBody, "body", "auto", bodyFlagUsage)
+	threadCmd.Flags().StringVar(&threadFormat, "format", "text",
+		"Output format: text|markdown (markdown puts the thread headers in a front matter block)")
+	threadCmd.Flags().BoolVar(&threadStrip, "strip-quotes", false,
+		"Remove quoted replies and signatures from each message body")
+	threadCmd.Flags().BoolVar(&threadTree, "tree", false,
+		"Show the reply structure as a tree (nested replies in JSON)")
+	threadCmd.Flags().BoolVar(&threadSummary, "summary", false,
+		"Show an overview: participants, activity, attachments, labels and a timeline")
+	threadCmd.Flags().IntVar(&threadWorkers, "concurrency", defaultConcurrency,
+		"Number of threads to fetch at the same time")
 }
 
 var threadCmd = &cobra.Command{
-	Use:   "thread <id>",
-	Short: "Read a full conversation thread",
-	Long: `Read all messages in a Gmail conversation thread.
+	Use:   "thread <id>...",
+	Short: "Read full conversation threads",
+	Long: `Read all messages in one or more Gmail conversation threads.
 
 Accepts either a thread ID or a message ID. If a message ID is provided,
 the thread containing that message will be retrieved automatically.
 Use the search command to find message IDs (the ThreadID field can also
-be used directly).
+be used directly). Pass "-" to read newline-separated IDs from stdin.
+
+Several threads are fetched concurrently (see --concurrency) and printed in
+the order given; JSON output is then an array with one entry per thread and
+--fields prints the messages of all threads. IDs that cannot be fetched are
+reported on stderr without stopping the rest of the batch, and the command
+exits non-zero.
+
+HTML-only messages are rendered as plain text; see --body to choose the
+HTML, plain text or Markdown representation explicitly. JSON output keeps
+each body as sent.
+
+--format markdown prints the whole thread as one Markdown document: the
+thread headers go in a YAML front matter block, followed by a section per
+message with HTML bodies converted to Markdown.
+
+--strip-quotes removes the quoted history each reply repeats ("On ... wrote:"
+blocks, '>' lines, Outlook separators, Gmail quote sections) and common
+signatures, so each message shows only what its sender added. In JSON output
+the removed text is kept in the strippedText field.
+
+--tree arranges the messages by who replied to whom, using the Message-ID,
+In-Reply-To and References headers, which makes branched mailing list
+discussions easy to follow. JSON output nests each message's replies under
+"replies".
+
+--summary prints an overview instead of the messages: participants with the
+number of messages each sent, first and last activity, attachment count,
+labels and a one-line-per-message timeline.
 
 Examples:
   gmro thread 18abc123def456
-  gmro thread 18abc123def456 --json`,
-	Args: cobra.ExactArgs(1),
+  gmro thread 18abc123def456 --json
+  gmro thread 18abc123def456 --body text
+  gmro thread 18abc123def456 --format markdown > thread.md
+  gmro thread 18abc123def456 --strip-quotes
+  gmro thread 18abc123def456 --tree
+  gmro thread 18abc123def456 --summary
+  gmro thread 18abc123def456 --fields id,from.email,subject
+  gmro search "label:project" --ids-only | gmro thread - --summary`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := newGmailClient()
+		if err := validateFields(threadFields); err != nil {
+			return err
+		}
+		if err := validateBodyMode(threadBody); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(threadFormat, threadJSONOutput, threadFields); err != nil {
+			return err
+		}
+		if threadTree && (len(threadFields) > 0 || threadFormat == "markdown") {
+			return fmt.Errorf("--tree cannot be combined with --fields or --format markdown")
+		}
+		if threadSummary && (threadTree || len(threadFields) > 0 || threadFormat == "markdown") {
+			return fmt.Errorf("--summary cannot be combined with --tree, --fields or --format markdown")
+		}
+
+		if err := validateConcurrency(threadWorkers); err != nil {
+			return err
+		}
+		ids, err := collectIDs(args, os.Stdin)
 		if err != nil {
 			return err
 		}
 
-		messages, err := client.GetThread(args[0])
+		client, err := newGmailClient()
 		if err != nil {
 			return err
 		}
 
-		if len(messages) == 0 {
-			fmt.Println("No messages found in thread.")
-			return nil
+		results := fetchAll(ids, threadWorkers, client.GetThread)
+		if len(ids) == 1 && results[0].Err != nil {
+			return results[0].Err
 		}
+		failed := reportFetchErrors(os.Stderr, results)
 
-		if threadJSONOutput {
-			return printJSON(messages)
+		bodyMode := markdownBodyMode(threadFormat, threadBody)
+		threads := make([][]*gmail.Message, 0, len(results))
+		for _, r := range results {
+			if r.Err != nil {
+				continue
+			}
+			for _, msg := range r.Value {
+				if threadStrip {
+					stripQuotes(msg, bodyMode)
+				}
+			}
+			threads = append(threads, r.Value)
 		}
+		batch := len(ids) > 1
 
-		fmt.Printf("Thread contains %d message(s)\n\n", len(messages))
+		if err := printThreads(threads, batch); err != nil {
+			return err
+		}
+		if failed > 0 {
+			cmd.SilenceUsage = true
+		}
+		return batchError(failed, len(ids))
+	},
+}
+
+// printThreads prints fetched threads in the output mode chosen by the flags
+func printThreads(threads [][]*gmail.Message, batch bool) error {
+	if len(threadFields) > 0 {
+		var messages []*gmail.Message
+		for _, thread := range threads {
+			messages = append(messages, thread...)
+		}
+		return printMessageFields(messages, threadFields, threadJSONOutput, false)
+	}
+
+	if threadJSONOutput {
+		values := make([]any, 0, len(threads))
+		for _, messages := range threads {
+			switch {
+			case threadSummary:
+				values = append(values, gmail.SummarizeThread(messages))
+			case threadTree:
+				values = append(values, gmail.BuildThreadTree(messages))
+			default:
+				values = append(values, messages)
+			}
+		}
+		return printBatchJSON(values, batch)
+	}
+
+	for i, messages := range threads {
+		if batch {
+			if i > 0 {
+				fmt.Println()
+			}
+			// Markdown documents carry their own front matter header
+			if threadFormat != "markdown" {
+				fmt.Printf("=== Thread %d of %d ===\n", i+1, len(threads))
+			}
+		}
+		printThread(messages)
+	}
+	return nil
+}
+
+// printThread prints the messages of one thread as text or Markdown, which
+// is the only output that renders bodies for --body
+func printThread(messages []*gmail.Message) {
+	bodyMode := markdownBodyMode(threadFormat, threadBody)
+	for _, msg := range messages {
+		applyBodyMode(msg, bodyMode)
+	}
+
+	if len(messages) == 0 {
+		fmt.Println("No messages found in thread.")
+		return
+	}
+
+	if threadSummary {
+		printThreadSummary(os.Stdout, gmail.SummarizeThread(messages), time.Now())
+		return
+	}
+
+	if threadTree {
+		positions := make(map[*gmail.Message]int, len(messages))
 		for i, msg := range messages {
-			fmt.Printf("=== Message %d of %d ===\n", i+1, len(messages))
-			printMessageHeader(msg, MessagePrintOptions{
-				IncludeTo:   true,
-				IncludeBody: true,
-			})
-			fmt.Println()
+			positions[msg] = i + 1
 		}
+		fmt.Printf("Thread contains %d message(s)\n\n", len(messages))
+		printThreadTree(os.Stdout, gmail.BuildThreadTree(messages), positions)
+		return
+	}
 
-		return nil
-	},
+	if threadFormat == "markdown" {
+		printThreadMarkdown(os.Stdout, messages)
+		return
+	}
+
+	fmt.Printf("Thread contains %d message(s)\n\n", len(messages))
+	for i, msg := range messages {
+		fmt.Printf("=== Message %d of %d ===\n", i+1, len(messages))
+		printMessageHeader(msg, MessagePrintOptions{
+			IncludeTo:   true,
+			IncludeBody: true,
+		})
+		fmt.Println()
+	}
 }
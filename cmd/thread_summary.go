@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+)
+
+// printThreadSummary writes the overview printed by thread --summary
+func printThreadSummary(w io.Writer, s *gmail.ThreadSummary, now time.Time) {
+	fmt.Fprintf(w, "Subject:     %s\n", s.Subject)
+	fmt.Fprintf(w, "Thread:      %s\n", s.ThreadID)
+	fmt.Fprintf(w, "Messages:    %d\n", s.MessageCount)
+	if !s.FirstActivity.IsZero() {
+		fmt.Fprintf(w, "First:       %s\n", formatTime(s.FirstActivity, now))
+		fmt.Fprintf(w, "Last:        %s\n", formatTime(s.LastActivity, now))
+	}
+	fmt.Fprintf(w, "Attachments: %d\n", s.Attachments)
+	if len(s.Labels) > 0 {
+		fmt.Fprintf(w, "Labels:      %s\n", strings.Join(s.Labels, ", "))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Participants:")
+	for _, p := range s.Participants {
+		fmt.Fprintf(w, "  %3d  %s\n", p.Messages, formatAddress(gmail.Address{Name: p.Name, Email: p.Email}))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Timeline:")
+	for _, e := range s.Timeline {
+		date := ""
+		if e.Date != nil {
+			date = formatTime(*e.Date, now)
+		}
+		sender := e.From.Name
+		if sender == "" {
+			sender = e.From.Email
+		}
+		fmt.Fprintf(w, "  %s  %-20s  %s\n", date, truncate(sender, 20), truncate(e.Snippet, 60))
+	}
+}
+
+// formatAddress renders an address as "Name <email>" or just the email
+func formatAddress(a gmail.Address) string {
+	if a.Name == "" {
+		return a.Email
+	}
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
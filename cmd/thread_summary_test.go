@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintThreadSummary(t *testing.T) {
+	origLoc, origFormat := displayLocation, dateFormat
+	displayLocation, dateFormat = time.UTC, "iso"
+	defer func() { displayLocation, dateFormat = origLoc, origFormat }()
+
+	at := func(hour int) time.Time { return time.Date(2024, 1, 15, hour, 0, 0, 0, time.UTC) }
+	summary := &gmail.ThreadSummary{
+		ThreadID:     "t1",
+		Subject:      "Plan",
+		MessageCount: 2,
+		Participants: []gmail.Participant{
+			{Name: "Alice", Email: "alice@example.com", Messages: 1},
+			{Email: "bob@example.com", Messages: 1},
+		},
+		FirstActivity: at(9),
+		LastActivity:  at(10),
+		Attachments:   1,
+		Labels:        []string{"Work"},
+		Timeline: []gmail.TimelineEntry{
+			{ID: "m1", Date: timePtr(at(9)), From: gmail.Address{Name: "Alice", Email: "alice@example.com"}, Snippet: "Shall we meet?"},
+			{ID: "m2", Date: timePtr(at(10)), From: gmail.Address{Email: "bob@example.com"}, Snippet: "Sure"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printThreadSummary(&buf, summary, at(12))
+	assert.Equal(t, `Subject:     Plan
+Thread:      t1
+Messages:    2
+First:       2024-01-15 09:00
+Last:        2024-01-15 10:00
+Attachments: 1
+Labels:      Work
+
+Participants:
+    1  Alice <alice@example.com>
+    1  bob@example.com
+
+Timeline:
+  2024-01-15 09:00  Alice                 Shall we meet?
+  2024-01-15 10:00  bob@example.com       Sure
+`, buf.String())
+}
+
+func TestFormatAddress(t *testing.T) {
+	assert.Equal(t, "a@example.com", formatAddress(gmail.Address{Email: "a@example.com"}))
+	assert.Equal(t, "A <a@example.com>", formatAddress(gmail.Address{Name: "A", Email: "a@example.com"}))
+}
@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestThreadCommand(t *testing.T) {
 	t.Run("has correct use", func(t *testing.T) {
-		assert.Equal(t, "thread <id>", threadCmd.Use)
+		assert.Equal(t, "thread <id>...", threadCmd.Use)
 	})
 
-	t.Run("requires exactly one argument", func(t *testing.T) {
+	t.Run("requires at least one argument", func(t *testing.T) {
 		err := threadCmd.Args(threadCmd, []string{})
 		assert.Error(t, err)
 
@@ -19,7 +22,7 @@ func TestThreadCommand(t *testing.T) {
 		assert.NoError(t, err)
 
 		err = threadCmd.Args(threadCmd, []string{"thread1", "thread2"})
-		assert.Error(t, err)
+		assert.NoError(t, err)
 	})
 
 	t.Run("has json flag", func(t *testing.T) {
@@ -38,4 +41,55 @@ func TestThreadCommand(t *testing.T) {
 		assert.Contains(t, threadCmd.Long, "thread ID")
 		assert.Contains(t, threadCmd.Long, "message ID")
 	})
+
+	t.Run("has fields flag", func(t *testing.T) {
+		assert.NotNil(t, threadCmd.Flags().Lookup("fields"))
+	})
+
+	t.Run("has body flag", func(t *testing.T) {
+		flag := threadCmd.Flags().Lookup("body")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "auto", flag.DefValue)
+	})
+
+	t.Run("has format flag", func(t *testing.T) {
+		flag := threadCmd.Flags().Lookup("format")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "text", flag.DefValue)
+	})
+
+	t.Run("has summary flag", func(t *testing.T) {
+		assert.NotNil(t, threadCmd.Flags().Lookup("summary"))
+	})
+
+	t.Run("has tree flag", func(t *testing.T) {
+		assert.NotNil(t, threadCmd.Flags().Lookup("tree"))
+	})
+
+	t.Run("has strip-quotes flag", func(t *testing.T) {
+		flag := threadCmd.Flags().Lookup("strip-quotes")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "false", flag.DefValue)
+	})
+
+	t.Run("has concurrency flag", func(t *testing.T) {
+		flag := threadCmd.Flags().Lookup("concurrency")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "4", flag.DefValue)
+	})
+}
+
+func TestPrintThreadsJSONKeepsBody(t *testing.T) {
+	threadJSONOutput = true
+	defer func() { threadJSONOutput = false }()
+
+	out := captureStdout(t, func() {
+		require.NoError(t, printThreads([][]*gmail.Message{{htmlOnlyMessage()}}, false))
+	})
+
+	var messages []gmail.Message
+	require.NoError(t, json.Unmarshal([]byte(out), &messages))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "<p>Hello <b>world</b></p>", messages[0].Body)
+	assert.Equal(t, "text/html", messages[0].BodyMimeType)
 }
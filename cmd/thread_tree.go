@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+)
+
+// printThreadTree writes reply trees with one entry per message: its
+// position in the thread, sender, date and ID, followed by its snippet
+func printThreadTree(w io.Writer, roots []*gmail.ThreadNode, positions map[*gmail.Message]int) {
+	for _, root := range roots {
+		printThreadNode(w, root, positions, "", "")
+	}
+}
+
+func printThreadNode(w io.Writer, node *gmail.ThreadNode, positions map[*gmail.Message]int, linePrefix, childPrefix string) {
+	fmt.Fprintf(w, "%s[%d] %s  %s  (%s)\n", linePrefix, positions[node.Message],
+		messageSender(node.Message), formatMessageDate(node.Message), node.ID)
+
+	// The snippet lines up under the sender, continuing the tree's guides
+	guide := childPrefix
+	if len(node.Replies) > 0 {
+		guide += "│   "
+	} else {
+		guide += "    "
+	}
+	if snippet := strings.Join(strings.Fields(html.UnescapeString(node.Snippet)), " "); snippet != "" {
+		fmt.Fprintf(w, "%s%s\n", guide, truncate(snippet, 72))
+	}
+
+	for i, reply := range node.Replies {
+		if i == len(node.Replies)-1 {
+			printThreadNode(w, reply, positions, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printThreadNode(w, reply, positions, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// messageSender returns the sender's display name, falling back to the
+// address and then the raw From header
+func messageSender(msg *gmail.Message) string {
+	if len(msg.FromAddresses) > 0 {
+		if a := msg.FromAddresses[0]; a.Name != "" {
+			return a.Name
+		} else if a.Email != "" {
+			return a.Email
+		}
+	}
+	return msg.From
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/open-cli-collective/gmail-ro/internal/gmail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintThreadTree(t *testing.T) {
+	origLoc, origFormat := displayLocation, dateFormat
+	displayLocation, dateFormat = time.UTC, "iso"
+	defer func() { displayLocation, dateFormat = origLoc, origFormat }()
+
+	at := func(hour int) time.Time { return time.Date(2024, 1, 15, hour, 0, 0, 0, time.UTC) }
+	messages := []*gmail.Message{
+		{ID: "m1", MessageID: "<a@x>", DateParsed: timePtr(at(9)), Snippet: "Shall we meet?",
+			FromAddresses: []gmail.Address{{Name: "Alice", Email: "alice@example.com"}}},
+		{ID: "m2", MessageID: "<b@x>", InReplyTo: "<a@x>", DateParsed: timePtr(at(10)), Snippet: "Tuesday works",
+			FromAddresses: []gmail.Address{{Email: "bob@example.com"}}},
+		{ID: "m3", MessageID: "<c@x>", InReplyTo: "<a@x>", DateParsed: timePtr(at(11)), Snippet: "I can&#39;t make it",
+			From: "carol"},
+		{ID: "m4", InReplyTo: "<b@x>", DateParsed: timePtr(at(12)),
+			FromAddresses: []gmail.Address{{Name: "Alice", Email: "alice@example.com"}}},
+	}
+	positions := make(map[*gmail.Message]int)
+	for i, msg := range messages {
+		positions[msg] = i + 1
+	}
+
+	var buf bytes.Buffer
+	printThreadTree(&buf, gmail.BuildThreadTree(messages), positions)
+	assert.Equal(t, `[1] Alice  2024-01-15 09:00  (m1)
+│   Shall we meet?
+├── [2] bob@example.com  2024-01-15 10:00  (m2)
+│   │   Tuesday works
+│   └── [4] Alice  2024-01-15 12:00  (m4)
+└── [3] carol  2024-01-15 11:00  (m3)
+        I can't make it
+`, buf.String())
+}
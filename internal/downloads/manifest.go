@@ -0,0 +1,142 @@
+// Package downloads keeps the bookkeeping of attachment downloads: a manifest
+// of what was saved where, and a content-addressed store used to deduplicate
+// identical files.
+package downloads
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// DirName is the directory within the output directory that holds the
+	// manifest and the content store
+	DirName = ".gmro"
+	// ManifestFile is the name of the manifest within DirName
+	ManifestFile = "manifest.json"
+)
+
+// Entry statuses
+const (
+	StatusComplete = "complete"
+	StatusFailed   = "failed"
+)
+
+// Entry records the download of one attachment
+type Entry struct {
+	MessageID    string `json:"messageId"`
+	PartID       string `json:"partId"`
+	AttachmentID string `json:"attachmentId,omitempty"`
+	Filename     string `json:"filename"`
+	// Path is relative to the output directory and uses forward slashes. It
+	// is empty for a duplicate that was skipped; its content is in the store.
+	Path      string `json:"path,omitempty"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Complete reports whether the attachment was saved
+func (e Entry) Complete() bool {
+	return e.Status == StatusComplete
+}
+
+// Manifest maps message and part IDs to the files they were saved as,
+// backed by a JSON file
+type Manifest struct {
+	path    string
+	entries map[entryKey]Entry
+}
+
+type entryKey struct {
+	messageID string
+	partID    string
+}
+
+type manifestFile struct {
+	Entries []Entry `json:"entries"`
+}
+
+// ManifestPath returns the manifest location for an output directory
+func ManifestPath(outputDir string) string {
+	return filepath.Join(outputDir, DirName, ManifestFile)
+}
+
+// LoadManifest reads the manifest at path. A missing file yields an empty
+// manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{path: path, entries: make(map[entryKey]Entry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var f manifestFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	for _, e := range f.Entries {
+		m.Set(e)
+	}
+
+	return m, nil
+}
+
+// Set adds an entry, replacing any entry for the same message and part
+func (m *Manifest) Set(e Entry) {
+	m.entries[entryKey{e.MessageID, e.PartID}] = e
+}
+
+// Get returns the entry for a message part
+func (m *Manifest) Get(messageID, partID string) (Entry, bool) {
+	e, ok := m.entries[entryKey{messageID, partID}]
+	return e, ok
+}
+
+// Entries returns all entries ordered by message and part ID
+func (m *Manifest) Entries() []Entry {
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].MessageID != entries[j].MessageID {
+			return entries[i].MessageID < entries[j].MessageID
+		}
+		return entries[i].PartID < entries[j].PartID
+	})
+	return entries
+}
+
+// Save writes the manifest back to its file
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(manifestFile{Entries: m.Entries()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated file
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package downloads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", ".gmro", "manifest.json"), ManifestPath("out"))
+}
+
+func TestLoadManifest(t *testing.T) {
+	t.Run("missing file yields empty manifest", func(t *testing.T) {
+		m, err := LoadManifest(ManifestPath(t.TempDir()))
+		require.NoError(t, err)
+		assert.Empty(t, m.Entries())
+	})
+
+	t.Run("invalid JSON returns error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ManifestFile)
+		require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+
+		_, err := LoadManifest(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	path := ManifestPath(t.TempDir())
+
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	m.Set(Entry{MessageID: "b", PartID: "1", Filename: "logo.png", Path: "b/logo.png", Size: 10, SHA256: "abc"})
+	m.Set(Entry{MessageID: "a", PartID: "2", Filename: "x.pdf", Size: 5, SHA256: "def", Duplicate: true})
+	m.Set(Entry{MessageID: "a", PartID: "1", Filename: "old.pdf"})
+	m.Set(Entry{MessageID: "a", PartID: "1", Filename: "new.pdf", Path: "new.pdf"})
+	require.NoError(t, m.Save())
+
+	loaded, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{MessageID: "a", PartID: "1", Filename: "new.pdf", Path: "new.pdf"},
+		{MessageID: "a", PartID: "2", Filename: "x.pdf", Size: 5, SHA256: "def", Duplicate: true},
+		{MessageID: "b", PartID: "1", Filename: "logo.png", Path: "b/logo.png", Size: 10, SHA256: "abc"},
+	}, loaded.Entries())
+
+	e, ok := loaded.Get("b", "1")
+	assert.True(t, ok)
+	assert.Equal(t, "logo.png", e.Filename)
+	_, ok = loaded.Get("b", "2")
+	assert.False(t, ok)
+}
+
+func TestEntryComplete(t *testing.T) {
+	assert.True(t, Entry{Status: StatusComplete}.Complete())
+	assert.False(t, Entry{}.Complete())
+	assert.False(t, Entry{Status: StatusFailed}.Complete())
+}
@@ -0,0 +1,114 @@
+package downloads
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// objectsDir is the directory within DirName that holds stored content
+const objectsDir = "objects"
+
+// Store is a content-addressed store of downloaded files. Objects are named
+// by the SHA-256 of their content and made read-only, so what the store
+// holds under a hash cannot be edited by accident. The first download of
+// some content becomes the object itself through a hard link, and
+// duplicates are hard links to it, so all of them are read-only and the
+// content is on disk only once. It is safe for concurrent use.
+type Store struct {
+	dir  string
+	mu   sync.Mutex
+	link func(oldname, newname string) error
+}
+
+// NewStore returns the store for an output directory
+func NewStore(outputDir string) *Store {
+	return &Store{dir: filepath.Join(outputDir, DirName, objectsDir), link: os.Link}
+}
+
+// ObjectPath returns where content with the given hash is stored
+func (s *Store) ObjectPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Add stores the file at path under its content hash by hard linking it
+// into the store and making it read-only. When no hard link can be created
+// the file is copied instead, leaving the file at path as it is. When the
+// store already holds that content, nothing is changed and duplicate is
+// true.
+func (s *Store) Add(hash, path string) (duplicate bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object := s.ObjectPath(hash)
+	if _, err := os.Stat(object); err == nil {
+		return true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		return false, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	if err := s.link(path, object); err != nil {
+		if err := copyFile(path, object, 0444); err != nil {
+			return false, fmt.Errorf("failed to add %s to the store: %w", path, err)
+		}
+		return false, nil
+	}
+	if err := os.Chmod(object, 0444); err != nil {
+		_ = os.Remove(object)
+		return false, fmt.Errorf("failed to add %s to the store: %w", path, err)
+	}
+	return false, nil
+}
+
+// LinkTo replaces the file at path with a hard link to the stored content
+// with the given hash. When no hard link can be created, as on filesystems
+// such as exFAT or many network shares, the file, which already holds that
+// content, is left as it is and linked is false.
+func (s *Store) LinkTo(hash, path string) (linked bool, err error) {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".link")
+	_ = os.Remove(tmp)
+	if err := s.link(s.ObjectPath(hash), tmp); err != nil {
+		return false, nil
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return false, fmt.Errorf("failed to link %s to the store: %w", path, err)
+	}
+	return true, nil
+}
+
+// copyFile copies src to dst through a temporary file, so dst either holds
+// the whole content or does not exist
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
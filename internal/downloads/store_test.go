@@ -0,0 +1,86 @@
+package downloads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	assert.Equal(t, filepath.Join(dir, ".gmro", "objects", "2c", testHash), store.ObjectPath(testHash))
+
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	require.NoError(t, os.WriteFile(first, []byte("hello"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("hello"), 0644))
+
+	duplicate, err := store.Add(testHash, first)
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+
+	duplicate, err = store.Add(testHash, second)
+	require.NoError(t, err)
+	assert.True(t, duplicate)
+
+	linked, err := store.LinkTo(testHash, second)
+	require.NoError(t, err)
+	assert.True(t, linked)
+
+	// Both copies share the read-only stored object
+	object, err := os.Stat(store.ObjectPath(testHash))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), object.Mode().Perm())
+	info, err := os.Stat(second)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(object, info))
+	info, err = os.Stat(first)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(object, info))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "no temporary link files are left behind")
+
+	objects, err := os.ReadDir(filepath.Dir(store.ObjectPath(testHash)))
+	require.NoError(t, err)
+	assert.Len(t, objects, 1, "no temporary copies are left behind")
+}
+
+func TestStoreWithoutHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	store.link = func(string, string) error { return errors.New("operation not supported") }
+
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	require.NoError(t, os.WriteFile(first, []byte("hello"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("hello"), 0644))
+
+	_, err := store.Add(testHash, first)
+	require.NoError(t, err)
+
+	// The store holds a read-only copy and the first file stays writable
+	object, err := os.Stat(store.ObjectPath(testHash))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), object.Mode().Perm())
+	info, err := os.Stat(first)
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(object, info))
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	linked, err := store.LinkTo(testHash, second)
+	require.NoError(t, err)
+	assert.False(t, linked)
+
+	content, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content), "the downloaded copy is kept")
+}
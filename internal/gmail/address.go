@@ -0,0 +1,124 @@
+package gmail
+
+import (
+	"mime"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Address is a parsed email address from a From, To, Cc, Bcc or Reply-To header
+type Address struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// wordDecoder decodes RFC 2047 encoded words in display names
+var wordDecoder = &mime.WordDecoder{}
+
+// angleAddr matches the addr-spec inside angle brackets, tolerating a missing '>'
+var angleAddr = regexp.MustCompile(`<\s*([^<>\s]+@[^<>\s]+?)\s*(?:>|$)`)
+
+// parseAddressList parses an address header value into structured addresses.
+// Malformed headers are parsed entry by entry on a best-effort basis so that
+// one bad address does not discard the rest of the list.
+func parseAddressList(value string) []Address {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+	if list, err := parser.ParseList(value); err == nil {
+		addrs := make([]Address, 0, len(list))
+		for _, a := range list {
+			addrs = append(addrs, Address{Name: decodeDisplayName(a.Name), Email: a.Address})
+		}
+		return addrs
+	}
+
+	var addrs []Address
+	for _, entry := range splitAddressList(value) {
+		if a, err := parser.Parse(entry); err == nil {
+			addrs = append(addrs, Address{Name: decodeDisplayName(a.Name), Email: a.Address})
+			continue
+		}
+		if a, ok := salvageAddress(entry); ok {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// decodeDisplayName decodes encoded words that net/mail leaves alone, such as
+// those inside a quoted string
+func decodeDisplayName(name string) string {
+	if !strings.Contains(name, "=?") {
+		return name
+	}
+	if decoded, err := wordDecoder.DecodeHeader(name); err == nil {
+		return decoded
+	}
+	return name
+}
+
+// splitAddressList splits a header value on commas outside quoted strings.
+// Entries without an '@' are treated as part of the following entry's display
+// name, which recovers unquoted names such as "Public, John <jp@example.com>".
+func splitAddressList(value string) []string {
+	var raw []string
+	var current strings.Builder
+	inQuote, escaped := false, false
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case r == ',' && !inQuote:
+			raw = append(raw, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	raw = append(raw, current.String())
+
+	var entries []string
+	var prefix string
+	for _, entry := range raw {
+		if prefix != "" {
+			entry = prefix + "," + entry
+			prefix = ""
+		}
+		if !strings.Contains(entry, "@") {
+			prefix = entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// salvageAddress extracts whatever address it can from an entry net/mail rejected
+func salvageAddress(entry string) (Address, bool) {
+	entry = strings.TrimSpace(entry)
+
+	if m := angleAddr.FindStringSubmatchIndex(entry); m != nil {
+		name := strings.TrimSpace(entry[:m[0]])
+		name = strings.Trim(name, `"' `)
+		return Address{Name: decodeDisplayName(name), Email: entry[m[2]:m[3]]}, true
+	}
+
+	for _, token := range strings.Fields(entry) {
+		token = strings.Trim(token, `"'<>(),;`)
+		if strings.Contains(token, "@") {
+			return Address{Email: token}, true
+		}
+	}
+
+	return Address{}, false
+}
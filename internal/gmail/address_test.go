@@ -0,0 +1,69 @@
+package gmail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestParseAddressList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []Address
+	}{
+		{"empty", "", nil},
+		{"bare address", "alice@example.com", []Address{{Email: "alice@example.com"}}},
+		{"name and address", "Alice Smith <alice@example.com>", []Address{{Name: "Alice Smith", Email: "alice@example.com"}}},
+		{
+			"quoted name with comma",
+			`"Doe, John" <john@example.com>, bob@example.com`,
+			[]Address{{Name: "Doe, John", Email: "john@example.com"}, {Email: "bob@example.com"}},
+		},
+		{"RFC 2047 encoded name", "=?UTF-8?B?SsO8cmdlbg==?= <j@example.de>", []Address{{Name: "Jürgen", Email: "j@example.de"}}},
+		{"quoted RFC 2047 name", `"=?ISO-8859-1?Q?Andr=E9?=" <andre@example.fr>`, []Address{{Name: "André", Email: "andre@example.fr"}}},
+		{"group syntax", "team: a@example.com, b@example.com;", []Address{{Email: "a@example.com"}, {Email: "b@example.com"}}},
+		{"undisclosed recipients", "undisclosed-recipients:;", []Address{}},
+		{
+			"malformed entry does not drop the rest",
+			"Bob <bob@example.com, Carol <carol@example.com>",
+			[]Address{{Name: "Bob", Email: "bob@example.com"}, {Name: "Carol", Email: "carol@example.com"}},
+		},
+		{
+			"unquoted special characters",
+			"John Q. Public, Jr. <jqp@example.com>",
+			[]Address{{Name: "John Q. Public, Jr.", Email: "jqp@example.com"}},
+		},
+		{"garbage without address", "not an address", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAddressList(tt.value))
+		})
+	}
+}
+
+func TestParseMessageAddresses(t *testing.T) {
+	msg := &gmail.Message{
+		Id: "msg123",
+		Payload: &gmail.MessagePart{
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "From", Value: `"Alice" <alice@example.com>`},
+				{Name: "To", Value: "bob@example.com, Carol <carol@example.com>"},
+				{Name: "Cc", Value: "dave@example.com"},
+				{Name: "Reply-To", Value: "list@example.com"},
+			},
+		},
+	}
+
+	result := parseMessage(msg, false, nil)
+
+	assert.Equal(t, `"Alice" <alice@example.com>`, result.From)
+	assert.Equal(t, []Address{{Name: "Alice", Email: "alice@example.com"}}, result.FromAddresses)
+	assert.Equal(t, []Address{{Email: "bob@example.com"}, {Name: "Carol", Email: "carol@example.com"}}, result.ToAddresses)
+	assert.Equal(t, []Address{{Email: "dave@example.com"}}, result.CcAddresses)
+	assert.Equal(t, []Address{{Email: "list@example.com"}}, result.ReplyToAddresses)
+	assert.Nil(t, result.BccAddresses)
+}
@@ -1,6 +1,7 @@
 package gmail
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"strconv"
@@ -19,19 +20,14 @@ func (c *Client) GetAttachments(messageID string) ([]*Attachment, error) {
 	return extractAttachments(msg.Payload, ""), nil
 }
 
-// DownloadAttachment downloads a single attachment by message ID and attachment ID
+// DownloadAttachment downloads a single attachment by message ID and attachment ID.
+// Use StreamAttachment to write large attachments without holding them in memory.
 func (c *Client) DownloadAttachment(messageID string, attachmentID string) ([]byte, error) {
-	att, err := c.Service.Users.Messages.Attachments.Get(c.UserID, messageID, attachmentID).Do()
-	if err != nil {
-		return nil, fmt.Errorf("failed to download attachment: %w", err)
-	}
-
-	data, err := base64.URLEncoding.DecodeString(att.Data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode attachment data: %w", err)
+	var buf bytes.Buffer
+	if _, err := c.StreamAttachment(messageID, attachmentID, &buf); err != nil {
+		return nil, err
 	}
-
-	return data, nil
+	return buf.Bytes(), nil
 }
 
 // DownloadInlineAttachment downloads an attachment that has inline data
@@ -0,0 +1,69 @@
+package gmail
+
+import (
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"google.golang.org/api/gmail/v1"
+)
+
+// partCharset returns the lowercased charset parameter of a part's
+// Content-Type header, or "" if none is declared
+func partCharset(part *gmail.MessagePart) string {
+	for _, header := range part.Headers {
+		if !strings.EqualFold(header.Name, "Content-Type") {
+			continue
+		}
+		_, params, err := mime.ParseMediaType(header.Value)
+		if err != nil {
+			// Tolerate broken parameter lists and pick out the charset directly
+			params = looseMediaParams(header.Value)
+		}
+		return strings.ToLower(strings.Trim(params["charset"], `"' `))
+	}
+	return ""
+}
+
+// looseMediaParams splits a Content-Type value into parameters without
+// enforcing RFC 2045 syntax
+func looseMediaParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, field := range strings.Split(value, ";")[1:] {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(val)
+	}
+	return params
+}
+
+// decodeCharset converts text in the given charset to UTF-8. Charset names
+// are resolved with the WHATWG encoding labels, so aliases such as latin1,
+// cp1252, sjis and gb2312 are understood. Undecodable bytes and unknown
+// charsets never produce invalid UTF-8; bad sequences become U+FFFD.
+func decodeCharset(data []byte, charset string) string {
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return toValidUTF8(data)
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return toValidUTF8(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return toValidUTF8(data)
+	}
+	return toValidUTF8(decoded)
+}
+
+func toValidUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "\uFFFD")
+}
@@ -0,0 +1,82 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestPartCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		expected    string
+	}{
+		{"quoted", `text/plain; charset="ISO-8859-1"`, "iso-8859-1"},
+		{"unquoted", "text/html; charset=Shift_JIS", "shift_jis"},
+		{"missing", "text/plain", ""},
+		{"broken params", `text/plain; format=flowed; charset=gb2312; delsp`, "gb2312"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{
+				{Name: "content-type", Value: tt.contentType},
+			}}
+			assert.Equal(t, tt.expected, partCharset(part))
+		})
+	}
+
+	t.Run("no content type", func(t *testing.T) {
+		assert.Empty(t, partCharset(&gmail.MessagePart{}))
+	})
+}
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		charset  string
+		expected string
+	}{
+		{"utf-8", []byte("café"), "utf-8", "café"},
+		{"no charset", []byte("plain"), "", "plain"},
+		{"latin1", []byte{'c', 'a', 'f', 0xe9}, "iso-8859-1", "café"},
+		{"windows-1252 quotes", []byte{0x93, 'h', 'i', 0x94, ' ', 0x80}, "windows-1252", "“hi” €"},
+		{"shift_jis", []byte{0x93, 0xfa, 0x96, 0x7b}, "shift_jis", "日本"},
+		{"gb2312", []byte{0xc4, 0xe3, 0xba, 0xc3}, "gb2312", "你好"},
+		{"invalid utf-8", []byte{'a', 0xff, 'b'}, "utf-8", "a�b"},
+		{"unknown charset", []byte{'a', 0xff, 'b'}, "x-unknown", "a�b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := decodeCharset(tt.data, tt.charset)
+			assert.Equal(t, tt.expected, result)
+			assert.True(t, utf8.ValidString(result))
+		})
+	}
+}
+
+func TestExtractBodyCharset(t *testing.T) {
+	payload := &gmail.MessagePart{
+		MimeType: "multipart/alternative",
+		Parts: []*gmail.MessagePart{
+			{
+				MimeType: "text/plain",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Content-Type", Value: `text/plain; charset="iso-8859-1"`},
+				},
+				Body: &gmail.MessagePartBody{
+					Data: base64.RawURLEncoding.EncodeToString([]byte{'d', 0xe9, 'j', 0xe0}),
+				},
+			},
+		},
+	}
+
+	body, charset, mimeType := extractBody(payload)
+	assert.Equal(t, "déjà", body)
+	assert.Equal(t, "iso-8859-1", charset)
+	assert.Equal(t, "text/plain", mimeType)
+}
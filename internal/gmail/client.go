@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/open-cli-collective/gmail-ro/internal/keychain"
 	"golang.org/x/oauth2"
@@ -25,6 +26,8 @@ const (
 type Client struct {
 	Service      *gmail.Service
 	UserID       string
+	httpClient   *http.Client
+	labelsMu     sync.Mutex
 	labels       map[string]*gmail.Label
 	labelsLoaded bool
 }
@@ -59,8 +62,9 @@ func NewClient(ctx context.Context) (*Client, error) {
 	}
 
 	return &Client{
-		Service: srv,
-		UserID:  "me",
+		Service:    srv,
+		UserID:     "me",
+		httpClient: client,
 	}, nil
 }
 
@@ -152,8 +156,11 @@ func saveToken(path string, token *oauth2.Token) error {
 	return json.NewEncoder(f).Encode(token)
 }
 
-// FetchLabels retrieves and caches all labels from the Gmail account
+// FetchLabels retrieves and caches all labels from the Gmail account.
+// It is safe to call from multiple goroutines.
 func (c *Client) FetchLabels() error {
+	c.labelsMu.Lock()
+	defer c.labelsMu.Unlock()
 	if c.labelsLoaded {
 		return nil
 	}
@@ -174,6 +181,8 @@ func (c *Client) FetchLabels() error {
 
 // GetLabelName resolves a label ID to its display name
 func (c *Client) GetLabelName(labelID string) string {
+	c.labelsMu.Lock()
+	defer c.labelsMu.Unlock()
 	if label, ok := c.labels[labelID]; ok {
 		return label.Name
 	}
@@ -182,6 +191,8 @@ func (c *Client) GetLabelName(labelID string) string {
 
 // GetLabels returns all cached labels
 func (c *Client) GetLabels() []*gmail.Label {
+	c.labelsMu.Lock()
+	defer c.labelsMu.Unlock()
 	if !c.labelsLoaded {
 		return nil
 	}
@@ -0,0 +1,62 @@
+package gmail
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateLayouts are fallbacks for Date headers that net/mail rejects
+var dateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Mon Jan 2 15:04:05 2006",
+	"Mon Jan 2 15:04:05 -0700 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+	time.RFC3339,
+}
+
+// trailingComment matches a trailing parenthesized comment such as "(UTC)"
+var trailingComment = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+
+// parseDateHeader parses a Date header value, tolerating the common
+// deviations from RFC 5322 seen in the wild. Returns false if no layout matches.
+func parseDateHeader(value string) (time.Time, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if t, err := mail.ParseDate(value); err == nil {
+		return t, true
+	}
+
+	value = trailingComment.ReplaceAllString(value, "")
+	if t, err := mail.ParseDate(value); err == nil {
+		return t, true
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// resolveMessageDate returns the parsed Date header, falling back to the
+// server-assigned internal date when the header is missing or unparseable.
+// It returns nil when neither is known.
+func resolveMessageDate(header string, internalDate *time.Time) *time.Time {
+	if t, ok := parseDateHeader(header); ok {
+		return &t
+	}
+	return internalDate
+}
@@ -0,0 +1,124 @@
+package gmail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestParseDateHeader(t *testing.T) {
+	expected := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"RFC 5322", "Mon, 1 Jan 2024 12:00:00 +0000"},
+		{"zero-padded day", "Mon, 01 Jan 2024 12:00:00 +0000"},
+		{"trailing zone comment", "Mon, 1 Jan 2024 12:00:00 +0000 (UTC)"},
+		{"no weekday", "1 Jan 2024 12:00:00 +0000"},
+		{"offset from UTC", "Mon, 1 Jan 2024 07:00:00 -0500"},
+		{"extra whitespace", "  Mon,  1 Jan 2024   12:00:00 +0000 "},
+		{"ANSI C", "Mon Jan 1 12:00:00 2024"},
+		{"RFC 3339", "2024-01-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := parseDateHeader(tt.value)
+			assert.True(t, ok)
+			assert.True(t, expected.Equal(result), "got %s", result)
+		})
+	}
+
+	t.Run("rejects garbage", func(t *testing.T) {
+		_, ok := parseDateHeader("sometime last week")
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects empty", func(t *testing.T) {
+		_, ok := parseDateHeader("")
+		assert.False(t, ok)
+	})
+}
+
+func TestParseMessageDates(t *testing.T) {
+	internal := time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC)
+
+	t.Run("parses Date header", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id:           "msg123",
+			InternalDate: internal.UnixMilli(),
+			Payload: &gmail.MessagePart{
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Date", Value: "Mon, 1 Jan 2024 07:00:00 -0500"},
+				},
+			},
+		}
+
+		result := parseMessage(msg, false, nil)
+
+		assert.Equal(t, "Mon, 1 Jan 2024 07:00:00 -0500", result.Date)
+		require.NotNil(t, result.DateParsed)
+		assert.True(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Equal(*result.DateParsed))
+		assert.Equal(t, &internal, result.InternalDate)
+	})
+
+	t.Run("falls back to internal date", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id:           "msg123",
+			InternalDate: internal.UnixMilli(),
+			Payload: &gmail.MessagePart{
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Date", Value: "not a date"},
+				},
+			},
+		}
+
+		result := parseMessage(msg, false, nil)
+
+		assert.Equal(t, &internal, result.DateParsed)
+	})
+
+	t.Run("uses internal date without payload", func(t *testing.T) {
+		msg := &gmail.Message{Id: "msg123", InternalDate: internal.UnixMilli()}
+
+		result := parseMessage(msg, false, nil)
+
+		assert.Equal(t, &internal, result.DateParsed)
+	})
+
+	t.Run("omits unknown dates from JSON", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id:      "msg123",
+			Payload: &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{{Name: "Subject", Value: "Hi"}}},
+		}
+
+		result := parseMessage(msg, false, nil)
+		assert.Nil(t, result.DateParsed)
+		assert.Nil(t, result.InternalDate)
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "dateParsed")
+		assert.NotContains(t, string(data), "internalDate")
+		assert.NotContains(t, string(data), "0001-01-01")
+	})
+
+	t.Run("includes known dates in JSON", func(t *testing.T) {
+		msg := &gmail.Message{Id: "msg123", InternalDate: internal.UnixMilli()}
+
+		data, err := json.Marshal(parseMessage(msg, false, nil))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"dateParsed":"2024-01-01T12:00:05Z"`)
+		assert.Contains(t, string(data), `"internalDate":"2024-01-01T12:00:05Z"`)
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,116 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Header is a single message header
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PartHeaders holds the MIME headers of one message part.
+// PartID uses the same numbering as Attachment.PartID; the top-level
+// message has an empty PartID.
+type PartHeaders struct {
+	PartID   string   `json:"partId"`
+	MimeType string   `json:"mimeType"`
+	Filename string   `json:"filename,omitempty"`
+	Headers  []Header `json:"headers"`
+}
+
+// GetHeaders retrieves all top-level headers of a message in their original order
+func (c *Client) GetHeaders(messageID string) ([]Header, error) {
+	// Metadata format without a metadataHeaders filter returns every header
+	msg, err := c.Service.Users.Messages.Get(c.UserID, messageID).Format("metadata").Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.Payload == nil {
+		return nil, nil
+	}
+	return convertHeaders(msg.Payload.Headers), nil
+}
+
+// GetPartHeaders retrieves the headers of the message and every nested MIME part,
+// in depth-first order
+func (c *Client) GetPartHeaders(messageID string) ([]*PartHeaders, error) {
+	msg, err := c.Service.Users.Messages.Get(c.UserID, messageID).Format("full").Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.Payload == nil {
+		return nil, nil
+	}
+	return collectPartHeaders(msg.Payload, ""), nil
+}
+
+// FindHeaders returns the values of all headers with the given name (case-insensitive)
+func FindHeaders(headers []Header, name string) []string {
+	var values []string
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			values = append(values, h.Value)
+		}
+	}
+	return values
+}
+
+func convertHeaders(headers []*gmail.MessagePartHeader) []Header {
+	result := make([]Header, 0, len(headers))
+	for _, h := range headers {
+		result = append(result, Header{Name: h.Name, Value: h.Value})
+	}
+	return result
+}
+
+// collectPartHeaders recursively gathers headers from a part and its children
+func collectPartHeaders(part *gmail.MessagePart, partPath string) []*PartHeaders {
+	result := []*PartHeaders{{
+		PartID:   partPath,
+		MimeType: part.MimeType,
+		Filename: part.Filename,
+		Headers:  convertHeaders(part.Headers),
+	}}
+
+	for i, child := range part.Parts {
+		result = append(result, collectPartHeaders(child, childPartPath(partPath, i))...)
+	}
+
+	return result
+}
+
+// childPartPath returns the part path of the i-th child of partPath
+func childPartPath(partPath string, i int) string {
+	if partPath == "" {
+		return fmt.Sprintf("%d", i)
+	}
+	return fmt.Sprintf("%s.%d", partPath, i)
+}
+
+// GetRawMessage retrieves the full RFC 5322 source of a message
+func (c *Client) GetRawMessage(messageID string) ([]byte, error) {
+	msg, err := c.Service.Users.Messages.Get(c.UserID, messageID).Format("raw").Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	data, err := decodeBase64URL(msg.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode raw message: %w", err)
+	}
+	return data, nil
+}
+
+// decodeBase64URL decodes Gmail's URL-safe base64, with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	if strings.HasSuffix(s, "=") || len(s)%4 == 0 {
+		return base64.URLEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
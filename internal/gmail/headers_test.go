@@ -0,0 +1,77 @@
+package gmail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestCollectPartHeaders(t *testing.T) {
+	payload := &gmail.MessagePart{
+		MimeType: "multipart/mixed",
+		Headers: []*gmail.MessagePartHeader{
+			{Name: "Received", Value: "from b"},
+			{Name: "Received", Value: "from a"},
+			{Name: "Subject", Value: "Hi"},
+		},
+		Parts: []*gmail.MessagePart{
+			{
+				MimeType: "multipart/alternative",
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "text/plain",
+						Headers:  []*gmail.MessagePartHeader{{Name: "Content-Type", Value: "text/plain; charset=utf-8"}},
+					},
+				},
+			},
+			{
+				MimeType: "application/pdf",
+				Filename: "report.pdf",
+				Headers:  []*gmail.MessagePartHeader{{Name: "Content-Disposition", Value: "attachment"}},
+			},
+		},
+	}
+
+	result := collectPartHeaders(payload, "")
+
+	require.Len(t, result, 4)
+	assert.Equal(t, "", result[0].PartID)
+	assert.Equal(t, []Header{
+		{Name: "Received", Value: "from b"},
+		{Name: "Received", Value: "from a"},
+		{Name: "Subject", Value: "Hi"},
+	}, result[0].Headers)
+	assert.Equal(t, "0", result[1].PartID)
+	assert.Empty(t, result[1].Headers)
+	assert.Equal(t, "0.0", result[2].PartID)
+	assert.Equal(t, "text/plain", result[2].MimeType)
+	assert.Equal(t, "1", result[3].PartID)
+	assert.Equal(t, "report.pdf", result[3].Filename)
+}
+
+func TestFindHeaders(t *testing.T) {
+	headers := []Header{
+		{Name: "Received", Value: "one"},
+		{Name: "Subject", Value: "Hi"},
+		{Name: "RECEIVED", Value: "two"},
+	}
+
+	assert.Equal(t, []string{"one", "two"}, FindHeaders(headers, "received"))
+	assert.Nil(t, FindHeaders(headers, "X-Missing"))
+}
+
+func TestDecodeBase64URL(t *testing.T) {
+	for _, input := range []string{"aGk_Pz4-", "aGk_Pz4-YQ==", "aGk_Pz4-YQ"} {
+		_, err := decodeBase64URL(input)
+		assert.NoError(t, err, input)
+	}
+
+	data, err := decodeBase64URL("aGk_Pz4-YQ")
+	require.NoError(t, err)
+	assert.Equal(t, "hi??>>a", string(data))
+
+	_, err = decodeBase64URL("not base64!")
+	assert.Error(t, err)
+}
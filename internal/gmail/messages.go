@@ -1,26 +1,44 @@
 package gmail
 
 import (
-	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
 	"google.golang.org/api/gmail/v1"
 )
 
 // Message represents a simplified email message
 type Message struct {
-	ID          string        `json:"id"`
-	ThreadID    string        `json:"threadId"`
-	Subject     string        `json:"subject"`
-	From        string        `json:"from"`
-	To          string        `json:"to"`
-	Date        string        `json:"date"`
-	Snippet     string        `json:"snippet"`
-	Body        string        `json:"body,omitempty"`
-	Attachments []*Attachment `json:"attachments,omitempty"`
-	Labels      []string      `json:"labels,omitempty"`
-	Categories  []string      `json:"categories,omitempty"`
+	ID               string        `json:"id"`
+	ThreadID         string        `json:"threadId"`
+	Subject          string        `json:"subject"`
+	From             string        `json:"from"`
+	To               string        `json:"to"`
+	Cc               string        `json:"cc,omitempty"`
+	Bcc              string        `json:"bcc,omitempty"`
+	ReplyTo          string        `json:"replyTo,omitempty"`
+	FromAddresses    []Address     `json:"fromAddresses,omitempty"`
+	ToAddresses      []Address     `json:"toAddresses,omitempty"`
+	CcAddresses      []Address     `json:"ccAddresses,omitempty"`
+	BccAddresses     []Address     `json:"bccAddresses,omitempty"`
+	ReplyToAddresses []Address     `json:"replyToAddresses,omitempty"`
+	Date             string        `json:"date"`
+	DateParsed       *time.Time    `json:"dateParsed,omitempty"`
+	InternalDate     *time.Time    `json:"internalDate,omitempty"`
+	MessageID        string        `json:"messageId,omitempty"`
+	InReplyTo        string        `json:"inReplyTo,omitempty"`
+	References       []string      `json:"references,omitempty"`
+	Snippet          string        `json:"snippet"`
+	SizeEstimate     int64         `json:"sizeEstimate,omitempty"`
+	Body             string        `json:"body,omitempty"`
+	BodyMimeType     string        `json:"bodyMimeType,omitempty"`
+	Charset          string        `json:"charset,omitempty"`
+	StrippedText     string        `json:"strippedText,omitempty"`
+	Parts            []*Part       `json:"parts,omitempty"`
+	Attachments      []*Attachment `json:"attachments,omitempty"`
+	Labels           []string      `json:"labels,omitempty"`
+	Categories       []string      `json:"categories,omitempty"`
 }
 
 // Attachment represents metadata about an email attachment
@@ -30,26 +48,31 @@ type Attachment struct {
 	Size         int64  `json:"size"`
 	AttachmentID string `json:"attachmentId,omitempty"`
 	PartID       string `json:"partId"`
+	ContentID    string `json:"contentId,omitempty"`
 	IsInline     bool   `json:"isInline"`
+	SHA256       string `json:"sha256,omitempty"`
 }
 
-// SearchMessages searches for messages matching the query.
+// metadataHeaders are the headers requested when fetching messages in metadata format
+var metadataHeaders = []string{
+	"Subject", "From", "To", "Cc", "Bcc", "Reply-To", "Date",
+	"Message-ID", "In-Reply-To", "References",
+}
+
+// SearchMessages searches for messages matching the query, following result
+// pages like ListMessageIDs, and fetches each match. A maxResults of zero or
+// less returns every match.
 // Returns messages, the count of messages that failed to fetch, and any error.
 func (c *Client) SearchMessages(query string, maxResults int64) ([]*Message, int, error) {
-	call := c.Service.Users.Messages.List(c.UserID).Q(query)
-	if maxResults > 0 {
-		call = call.MaxResults(maxResults)
-	}
-
-	resp, err := call.Do()
+	ids, err := c.ListMessageIDs(query, maxResults)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
+		return nil, 0, err
 	}
 
 	var messages []*Message
 	var skipped int
-	for _, msg := range resp.Messages {
-		m, err := c.GetMessage(msg.Id, false)
+	for _, id := range ids {
+		m, err := c.GetMessage(id, false)
 		if err != nil {
 			skipped++
 			continue
@@ -60,6 +83,41 @@ func (c *Client) SearchMessages(query string, maxResults int64) ([]*Message, int
 	return messages, skipped, nil
 }
 
+// listPageSize is the largest page the messages.list endpoint returns
+const listPageSize = 500
+
+// ListMessageIDs returns the IDs of messages matching the query without
+// fetching the messages, following result pages until maxResults IDs have
+// been collected. A maxResults of zero or less returns every match.
+func (c *Client) ListMessageIDs(query string, maxResults int64) ([]string, error) {
+	var ids []string
+	pageToken := ""
+	for {
+		pageSize := int64(listPageSize)
+		if maxResults > 0 {
+			pageSize = min(pageSize, maxResults-int64(len(ids)))
+		}
+
+		call := c.Service.Users.Messages.List(c.UserID).Q(query).MaxResults(pageSize)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to search messages: %w", err)
+		}
+
+		for _, msg := range resp.Messages {
+			ids = append(ids, msg.Id)
+		}
+
+		pageToken = resp.NextPageToken
+		if pageToken == "" || (maxResults > 0 && int64(len(ids)) >= maxResults) {
+			return ids, nil
+		}
+	}
+}
+
 // GetMessage retrieves a single message by ID
 func (c *Client) GetMessage(messageID string, includeBody bool) (*Message, error) {
 	format := "metadata"
@@ -72,7 +130,12 @@ func (c *Client) GetMessage(messageID string, includeBody bool) (*Message, error
 		return nil, err
 	}
 
-	msg, err := c.Service.Users.Messages.Get(c.UserID, messageID).Format(format).Do()
+	call := c.Service.Users.Messages.Get(c.UserID, messageID).Format(format)
+	if !includeBody {
+		call = call.MetadataHeaders(metadataHeaders...)
+	}
+
+	msg, err := call.Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
@@ -117,11 +180,19 @@ type LabelResolver func(labelID string) string
 
 func parseMessage(msg *gmail.Message, includeBody bool, resolver LabelResolver) *Message {
 	m := &Message{
-		ID:       msg.Id,
-		ThreadID: msg.ThreadId,
-		Snippet:  msg.Snippet,
+		ID:           msg.Id,
+		ThreadID:     msg.ThreadId,
+		Snippet:      msg.Snippet,
+		SizeEstimate: msg.SizeEstimate,
 	}
 
+	// internalDate is milliseconds since the epoch, assigned by Gmail on receipt
+	if msg.InternalDate != 0 {
+		internal := time.UnixMilli(msg.InternalDate).UTC()
+		m.InternalDate = &internal
+	}
+	m.DateParsed = m.InternalDate
+
 	// Extract labels and categories (doesn't need Payload)
 	m.Labels, m.Categories = extractLabelsAndCategories(msg.LabelIds, resolver)
 
@@ -139,13 +210,35 @@ func parseMessage(msg *gmail.Message, includeBody bool, resolver LabelResolver)
 			m.From = header.Value
 		case "to":
 			m.To = header.Value
+		case "cc":
+			m.Cc = header.Value
+		case "bcc":
+			// Only present on messages the user sent
+			m.Bcc = header.Value
+		case "reply-to":
+			m.ReplyTo = header.Value
 		case "date":
 			m.Date = header.Value
+		case "message-id":
+			m.MessageID = strings.TrimSpace(header.Value)
+		case "in-reply-to":
+			m.InReplyTo = strings.TrimSpace(header.Value)
+		case "references":
+			m.References = strings.Fields(header.Value)
 		}
 	}
+	m.DateParsed = resolveMessageDate(m.Date, m.InternalDate)
+	m.FromAddresses = parseAddressList(m.From)
+	m.ToAddresses = parseAddressList(m.To)
+	m.CcAddresses = parseAddressList(m.Cc)
+	m.BccAddresses = parseAddressList(m.Bcc)
+	m.ReplyToAddresses = parseAddressList(m.ReplyTo)
 
 	if includeBody {
-		m.Body = extractBody(msg.Payload)
+		m.Body, m.Charset, m.BodyMimeType = extractBody(msg.Payload)
+		if root := buildParts(msg.Payload, ""); root != nil {
+			m.Parts = []*Part{root}
+		}
 		m.Attachments = extractAttachments(msg.Payload, "")
 	}
 
@@ -197,10 +290,11 @@ func extractAttachments(payload *gmail.MessagePart, partPath string) []*Attachme
 	// Check if this part is an attachment
 	if isAttachment(payload) {
 		att := &Attachment{
-			Filename: payload.Filename,
-			MimeType: payload.MimeType,
-			PartID:   partPath,
-			IsInline: isInlineAttachment(payload),
+			Filename:  payload.Filename,
+			MimeType:  payload.MimeType,
+			PartID:    partPath,
+			ContentID: partContentID(payload),
+			IsInline:  isInlineAttachment(payload),
 		}
 		if payload.Body != nil {
 			att.Size = payload.Body.Size
@@ -211,11 +305,7 @@ func extractAttachments(payload *gmail.MessagePart, partPath string) []*Attachme
 
 	// Recursively check nested parts
 	for i, part := range payload.Parts {
-		childPath := fmt.Sprintf("%d", i)
-		if partPath != "" {
-			childPath = fmt.Sprintf("%s.%d", partPath, i)
-		}
-		attachments = append(attachments, extractAttachments(part, childPath)...)
+		attachments = append(attachments, extractAttachments(part, childPartPath(partPath, i))...)
 	}
 
 	return attachments
@@ -251,31 +341,42 @@ func isInlineAttachment(part *gmail.MessagePart) bool {
 	return false
 }
 
-func extractBody(payload *gmail.MessagePart) string {
+// partContentID returns the Content-ID of a part without its angle brackets.
+// HTML bodies reference inline images by this ID using cid: URLs.
+func partContentID(part *gmail.MessagePart) string {
+	return strings.Trim(partHeader(part, "Content-ID"), "<>")
+}
+
+// extractBody returns the message body as UTF-8 along with the charset the
+// body part declared and the MIME type of the part it came from
+func extractBody(payload *gmail.MessagePart) (string, string, string) {
 	// Try plain text first, then fall back to HTML
 	for _, mimeType := range []string{"text/plain", "text/html"} {
-		if body := findBodyByMimeType(payload, mimeType); body != "" {
-			return body
+		if body, charset := findBodyByMimeType(payload, mimeType); body != "" {
+			return body, charset, mimeType
 		}
 	}
-	return ""
+	return "", "", ""
 }
 
-// findBodyByMimeType searches for body content matching the given MIME type
-func findBodyByMimeType(part *gmail.MessagePart, mimeType string) string {
+// findBodyByMimeType searches for body content matching the given MIME type.
+// Gmail has already removed the Content-Transfer-Encoding, leaving only its
+// own base64url layer; the result is converted from the part's charset.
+func findBodyByMimeType(part *gmail.MessagePart, mimeType string) (string, string) {
 	// Check current part
-	if part.MimeType == mimeType && part.Body != nil && part.Body.Data != "" {
-		if decoded, err := base64.URLEncoding.DecodeString(part.Body.Data); err == nil {
-			return string(decoded)
+	if strings.EqualFold(part.MimeType, mimeType) && part.Body != nil && part.Body.Data != "" {
+		if decoded, err := decodeBase64URL(part.Body.Data); err == nil {
+			charset := partCharset(part)
+			return decodeCharset(decoded, charset), charset
 		}
 	}
 
 	// Check nested parts recursively
 	for _, child := range part.Parts {
-		if body := findBodyByMimeType(child, mimeType); body != "" {
-			return body
+		if body, charset := findBodyByMimeType(child, mimeType); body != "" {
+			return body, charset
 		}
 	}
 
-	return ""
+	return "", ""
 }
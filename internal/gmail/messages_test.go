@@ -2,9 +2,14 @@ package gmail
 
 import (
 	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/api/gmail/v1"
 )
 
@@ -118,7 +123,7 @@ func TestExtractBody(t *testing.T) {
 			},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Equal(t, bodyText, result)
 	})
 
@@ -144,7 +149,7 @@ func TestExtractBody(t *testing.T) {
 			},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Equal(t, bodyText, result)
 	})
 
@@ -159,7 +164,7 @@ func TestExtractBody(t *testing.T) {
 			},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Equal(t, htmlContent, result)
 	})
 
@@ -184,7 +189,7 @@ func TestExtractBody(t *testing.T) {
 			},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Equal(t, bodyText, result)
 	})
 
@@ -194,7 +199,7 @@ func TestExtractBody(t *testing.T) {
 			Body:     &gmail.MessagePartBody{},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Empty(t, result)
 	})
 
@@ -203,7 +208,7 @@ func TestExtractBody(t *testing.T) {
 			MimeType: "text/plain",
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Empty(t, result)
 	})
 
@@ -215,7 +220,7 @@ func TestExtractBody(t *testing.T) {
 			},
 		}
 
-		result := extractBody(payload)
+		result, _, _ := extractBody(payload)
 		assert.Empty(t, result)
 	})
 }
@@ -286,6 +291,29 @@ func TestParseMessageWithBody(t *testing.T) {
 		result := parseMessage(msg, false, nil)
 		assert.Empty(t, result.Body)
 	})
+	t.Run("builds parts tree alongside plain text", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id: "msg123",
+			Payload: &gmail.MessagePart{
+				MimeType: "multipart/alternative",
+				Parts: []*gmail.MessagePart{
+					{MimeType: "text/plain", Body: &gmail.MessagePartBody{
+						Data: base64.URLEncoding.EncodeToString([]byte("Plain")),
+					}},
+					{MimeType: "text/html", Body: &gmail.MessagePartBody{
+						Data: base64.URLEncoding.EncodeToString([]byte("<p>Rich</p>")),
+					}},
+				},
+			},
+		}
+
+		result := parseMessage(msg, true, nil)
+		assert.Equal(t, "Plain", result.Body)
+		assert.Equal(t, "text/plain", result.BodyMimeType)
+		require.Len(t, result.Parts, 1)
+		require.Len(t, result.Parts[0].Parts, 2)
+		assert.Equal(t, "<p>Rich</p>", result.Parts[0].Parts[1].Content)
+	})
 }
 
 func TestExtractAttachments(t *testing.T) {
@@ -654,3 +682,101 @@ func TestParseMessageWithLabels(t *testing.T) {
 		assert.Empty(t, result.Categories)
 	})
 }
+
+func TestParseMessageExtendedHeaders(t *testing.T) {
+	t.Run("extracts recipient and threading headers", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id: "msg123",
+			Payload: &gmail.MessagePart{
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Cc", Value: "carol@example.com, dave@example.com"},
+					{Name: "Bcc", Value: "eve@example.com"},
+					{Name: "Reply-To", Value: "list@example.com"},
+					{Name: "Message-ID", Value: " <abc@mail.example.com> "},
+					{Name: "In-Reply-To", Value: "<parent@mail.example.com>"},
+					{Name: "References", Value: "<root@mail.example.com>\r\n <parent@mail.example.com>"},
+				},
+			},
+		}
+
+		result := parseMessage(msg, false, nil)
+
+		assert.Equal(t, "carol@example.com, dave@example.com", result.Cc)
+		assert.Equal(t, "eve@example.com", result.Bcc)
+		assert.Equal(t, "list@example.com", result.ReplyTo)
+		assert.Equal(t, "<abc@mail.example.com>", result.MessageID)
+		assert.Equal(t, "<parent@mail.example.com>", result.InReplyTo)
+		assert.Equal(t, []string{"<root@mail.example.com>", "<parent@mail.example.com>"}, result.References)
+	})
+
+	t.Run("handles case-insensitive threading headers", func(t *testing.T) {
+		msg := &gmail.Message{
+			Id: "msg123",
+			Payload: &gmail.MessagePart{
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Message-Id", Value: "<abc@x>"},
+					{Name: "CC", Value: "carol@example.com"},
+				},
+			},
+		}
+
+		result := parseMessage(msg, false, nil)
+
+		assert.Equal(t, "<abc@x>", result.MessageID)
+		assert.Equal(t, "carol@example.com", result.Cc)
+		assert.Empty(t, result.Bcc)
+		assert.Nil(t, result.References)
+	})
+}
+
+func TestMetadataHeaders(t *testing.T) {
+	for _, name := range []string{"Subject", "From", "To", "Cc", "Bcc", "Reply-To", "Date", "Message-ID", "In-Reply-To", "References"} {
+		assert.Contains(t, metadataHeaders, name)
+	}
+}
+
+// pagedSearchHandler serves total message IDs in pages of at most 500 and a
+// minimal message for each ID, recording the page sizes requested
+func pagedSearchHandler(t *testing.T, total int, pageSizes *[]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/labels"):
+			fmt.Fprint(w, `{"labels": []}`)
+		case strings.HasSuffix(r.URL.Path, "/messages"):
+			size, err := strconv.Atoi(r.URL.Query().Get("maxResults"))
+			require.NoError(t, err)
+			*pageSizes = append(*pageSizes, size)
+
+			start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+			end := min(start+size, total)
+			var ids []string
+			for i := start; i < end; i++ {
+				ids = append(ids, fmt.Sprintf(`{"id": "m%d"}`, i))
+			}
+			next := ""
+			if end < total {
+				next = strconv.Itoa(end)
+			}
+			fmt.Fprintf(w, `{"messages": [%s], "nextPageToken": %q}`, strings.Join(ids, ","), next)
+		default:
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			fmt.Fprintf(w, `{"id": %q, "threadId": "t1"}`, id)
+		}
+	}
+}
+
+func TestSearchMessagesPaginates(t *testing.T) {
+	var pageSizes []int
+	client := newTestClient(t, pagedSearchHandler(t, 1200, &pageSizes))
+
+	messages, skipped, err := client.SearchMessages("in:inbox", 1000)
+	require.NoError(t, err)
+	assert.Zero(t, skipped)
+	require.Len(t, messages, 1000)
+	assert.Equal(t, "m999", messages[999].ID)
+	assert.Equal(t, []int{500, 500}, pageSizes)
+
+	ids, err := client.ListMessageIDs("in:inbox", 1000)
+	require.NoError(t, err)
+	assert.Len(t, ids, len(messages), "search and ID listing agree")
+}
@@ -0,0 +1,87 @@
+package gmail
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// MIMEPart is a node of a message's complete MIME structure, as Gmail sees
+// it. PartID uses the same numbering as Attachment.PartID.
+type MIMEPart struct {
+	PartID       string      `json:"partId"`
+	MimeType     string      `json:"mimeType"`
+	Filename     string      `json:"filename,omitempty"`
+	Disposition  string      `json:"disposition,omitempty"`
+	ContentID    string      `json:"contentId,omitempty"`
+	Charset      string      `json:"charset,omitempty"`
+	Encoding     string      `json:"encoding,omitempty"`
+	Size         int64       `json:"size"`
+	AttachmentID string      `json:"attachmentId,omitempty"`
+	IsAttachment bool        `json:"isAttachment"`
+	Parts        []*MIMEPart `json:"parts,omitempty"`
+}
+
+// GetMIMETree retrieves the MIME structure of a message without decoding any content
+func (c *Client) GetMIMETree(messageID string) (*MIMEPart, error) {
+	msg, err := c.Service.Users.Messages.Get(c.UserID, messageID).Format("full").Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.Payload == nil {
+		return nil, fmt.Errorf("message %s has no payload", messageID)
+	}
+	return buildMIMETree(msg.Payload, ""), nil
+}
+
+// buildMIMETree converts a Gmail payload into a MIMEPart tree. IsAttachment
+// reports whether the attachments commands list the part.
+func buildMIMETree(part *gmail.MessagePart, partPath string) *MIMEPart {
+	p := &MIMEPart{
+		PartID:       partPath,
+		MimeType:     part.MimeType,
+		Filename:     part.Filename,
+		Disposition:  partDisposition(part),
+		ContentID:    partContentID(part),
+		Charset:      partCharset(part),
+		Encoding:     strings.ToLower(partHeader(part, "Content-Transfer-Encoding")),
+		IsAttachment: isAttachment(part),
+	}
+	if part.Body != nil {
+		p.Size = part.Body.Size
+		p.AttachmentID = part.Body.AttachmentId
+	}
+
+	for i, child := range part.Parts {
+		p.Parts = append(p.Parts, buildMIMETree(child, childPartPath(partPath, i)))
+	}
+
+	return p
+}
+
+// partDisposition returns the lowercased Content-Disposition type of a part,
+// e.g. "inline" or "attachment"
+func partDisposition(part *gmail.MessagePart) string {
+	value := partHeader(part, "Content-Disposition")
+	if value == "" {
+		return ""
+	}
+	if disposition, _, err := mime.ParseMediaType(value); err == nil {
+		return disposition
+	}
+	disposition, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(disposition))
+}
+
+// partHeader returns the trimmed value of the first header of a part with
+// the given name (case-insensitive)
+func partHeader(part *gmail.MessagePart, name string) string {
+	for _, header := range part.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return strings.TrimSpace(header.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package gmail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestBuildMIMETree(t *testing.T) {
+	payload := &gmail.MessagePart{
+		MimeType: "multipart/related",
+		Parts: []*gmail.MessagePart{
+			{
+				MimeType: "text/html",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Content-Type", Value: `text/html; charset="UTF-8"`},
+					{Name: "Content-Transfer-Encoding", Value: "Quoted-Printable"},
+				},
+				Body: &gmail.MessagePartBody{Size: 300, Data: "PHA-"},
+			},
+			{
+				MimeType: "image/png",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Content-Disposition", Value: `inline; filename="logo.png"`},
+					{Name: "Content-ID", Value: "<logo@example.com>"},
+				},
+				Body: &gmail.MessagePartBody{Size: 1234, AttachmentId: "att1"},
+			},
+		},
+	}
+
+	tree := buildMIMETree(payload, "")
+	assert.Equal(t, "", tree.PartID)
+	assert.Equal(t, "multipart/related", tree.MimeType)
+	require.Len(t, tree.Parts, 2)
+
+	assert.Equal(t, &MIMEPart{
+		PartID: "0", MimeType: "text/html", Charset: "utf-8", Encoding: "quoted-printable", Size: 300,
+	}, tree.Parts[0])
+
+	assert.Equal(t, &MIMEPart{
+		PartID: "1", MimeType: "image/png", Disposition: "inline", ContentID: "logo@example.com",
+		Size: 1234, AttachmentID: "att1",
+	}, tree.Parts[1])
+}
+
+func TestPartDisposition(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "",
+		"attachment":                       "attachment",
+		`Attachment; filename="a b.pdf"`:   "attachment",
+		"inline; filename=broken name.png": "inline",
+	}
+	for value, expected := range tests {
+		part := &gmail.MessagePart{}
+		if value != "" {
+			part.Headers = []*gmail.MessagePartHeader{{Name: "content-disposition", Value: value}}
+		}
+		assert.Equal(t, expected, partDisposition(part), value)
+	}
+}
@@ -0,0 +1,106 @@
+package gmail
+
+import (
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Part is a node of a message's MIME tree. Only multipart containers and
+// inline text parts are included; attachments are listed in
+// Message.Attachments. Text parts carry their content decoded to UTF-8.
+type Part struct {
+	PartID   string  `json:"partId"`
+	MimeType string  `json:"mimeType"`
+	Charset  string  `json:"charset,omitempty"`
+	Size     int64   `json:"size,omitempty"`
+	Content  string  `json:"content,omitempty"`
+	Parts    []*Part `json:"parts,omitempty"`
+}
+
+// IsText reports whether the part is a displayable text/plain or text/html part
+func (p *Part) IsText() bool {
+	return p.MimeType == "text/plain" || p.MimeType == "text/html"
+}
+
+// buildParts converts a Gmail payload into a Part tree, returning nil if the
+// part holds no text
+func buildParts(part *gmail.MessagePart, partPath string) *Part {
+	p := &Part{PartID: partPath, MimeType: strings.ToLower(part.MimeType)}
+
+	if len(part.Parts) > 0 {
+		for i, child := range part.Parts {
+			if c := buildParts(child, childPartPath(partPath, i)); c != nil {
+				p.Parts = append(p.Parts, c)
+			}
+		}
+		if len(p.Parts) == 0 {
+			return nil
+		}
+		return p
+	}
+
+	if !strings.HasPrefix(p.MimeType, "text/") || isAttachment(part) || part.Body == nil || part.Body.Data == "" {
+		return nil
+	}
+	data, err := decodeBase64URL(part.Body.Data)
+	if err != nil {
+		return nil
+	}
+	p.Charset = partCharset(part)
+	p.Content = decodeCharset(data, p.Charset)
+	p.Size = int64(len(data))
+	return p
+}
+
+// DisplayParts returns the text parts a mail client would show, in order.
+// Every displayable part of a multipart/mixed (or other) container is
+// included, such as a forwarded message or a footer added by a list server.
+// Of a multipart/alternative only one alternative is used: the one holding
+// text/plain, or text/html when preferHTML is set. Ties go to the later
+// alternative, which RFC 2046 defines as the most faithful.
+func DisplayParts(parts []*Part, preferHTML bool) []*Part {
+	var result []*Part
+	for _, p := range parts {
+		result = append(result, displayParts(p, preferHTML)...)
+	}
+	return result
+}
+
+func displayParts(p *Part, preferHTML bool) []*Part {
+	if len(p.Parts) == 0 {
+		if p.IsText() && p.Content != "" {
+			return []*Part{p}
+		}
+		return nil
+	}
+
+	if p.MimeType != "multipart/alternative" {
+		return DisplayParts(p.Parts, preferHTML)
+	}
+
+	preferred := "text/plain"
+	if preferHTML {
+		preferred = "text/html"
+	}
+
+	var best []*Part
+	bestScore := 0
+	for _, child := range p.Parts {
+		selected := displayParts(child, preferHTML)
+		if len(selected) == 0 {
+			continue
+		}
+		score := 1
+		for _, s := range selected {
+			if s.MimeType == preferred {
+				score = 2
+				break
+			}
+		}
+		if score >= bestScore {
+			best, bestScore = selected, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,98 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func textPart(mimeType, content string) *gmail.MessagePart {
+	return &gmail.MessagePart{
+		MimeType: mimeType,
+		Body:     &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte(content))},
+	}
+}
+
+func TestBuildParts(t *testing.T) {
+	latin1 := textPart("text/plain", "")
+	latin1.Body.Data = base64.URLEncoding.EncodeToString([]byte{'c', 'a', 'f', 0xe9})
+	latin1.Headers = []*gmail.MessagePartHeader{{Name: "Content-Type", Value: "text/plain; charset=iso-8859-1"}}
+
+	attachment := textPart("text/plain", "notes")
+	attachment.Filename = "notes.txt"
+
+	payload := &gmail.MessagePart{
+		MimeType: "multipart/mixed",
+		Parts: []*gmail.MessagePart{
+			{
+				MimeType: "multipart/alternative",
+				Parts: []*gmail.MessagePart{
+					latin1,
+					textPart("text/html", "<p>café</p>"),
+				},
+			},
+			attachment,
+			{MimeType: "image/png", Filename: "logo.png", Body: &gmail.MessagePartBody{AttachmentId: "att1"}},
+			textPart("text/plain", "footer"),
+		},
+	}
+
+	root := buildParts(payload, "")
+	require.NotNil(t, root)
+	assert.Equal(t, "", root.PartID)
+	assert.Equal(t, "multipart/mixed", root.MimeType)
+	require.Len(t, root.Parts, 2)
+
+	alt := root.Parts[0]
+	assert.Equal(t, "0", alt.PartID)
+	require.Len(t, alt.Parts, 2)
+	assert.Equal(t, &Part{PartID: "0.0", MimeType: "text/plain", Charset: "iso-8859-1", Size: 4, Content: "café"}, alt.Parts[0])
+	assert.Equal(t, "0.1", alt.Parts[1].PartID)
+
+	assert.Equal(t, "3", root.Parts[1].PartID)
+	assert.Equal(t, "footer", root.Parts[1].Content)
+
+	t.Run("no text parts", func(t *testing.T) {
+		assert.Nil(t, buildParts(&gmail.MessagePart{MimeType: "image/png"}, ""))
+	})
+}
+
+func TestDisplayParts(t *testing.T) {
+	plain := &Part{PartID: "0.0", MimeType: "text/plain", Content: "plain"}
+	html := &Part{PartID: "0.1", MimeType: "text/html", Content: "<p>html</p>"}
+	footer := &Part{PartID: "1", MimeType: "text/plain", Content: "footer"}
+	calendar := &Part{PartID: "2", MimeType: "text/calendar", Content: "BEGIN:VCALENDAR"}
+
+	tree := []*Part{{
+		MimeType: "multipart/mixed",
+		Parts: []*Part{
+			{PartID: "0", MimeType: "multipart/alternative", Parts: []*Part{plain, html}},
+			footer,
+			calendar,
+		},
+	}}
+
+	assert.Equal(t, []*Part{plain, footer}, DisplayParts(tree, false))
+	assert.Equal(t, []*Part{html, footer}, DisplayParts(tree, true))
+
+	t.Run("alternative falls back to the other type", func(t *testing.T) {
+		htmlOnly := []*Part{{MimeType: "multipart/alternative", Parts: []*Part{html}}}
+		assert.Equal(t, []*Part{html}, DisplayParts(htmlOnly, false))
+	})
+
+	t.Run("related html inside alternative", func(t *testing.T) {
+		related := &Part{MimeType: "multipart/related", Parts: []*Part{html}}
+		alt := []*Part{{MimeType: "multipart/alternative", Parts: []*Part{plain, related}}}
+		assert.Equal(t, []*Part{html}, DisplayParts(alt, true))
+		assert.Equal(t, []*Part{plain}, DisplayParts(alt, false))
+	})
+
+	t.Run("later alternative wins ties", func(t *testing.T) {
+		second := &Part{MimeType: "text/plain", Content: "second"}
+		alt := []*Part{{MimeType: "multipart/alternative", Parts: []*Part{plain, second}}}
+		assert.Equal(t, []*Part{second}, DisplayParts(alt, false))
+	})
+}
@@ -0,0 +1,113 @@
+package gmail
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SearchCriteria holds structured search filters that compile to Gmail search syntax.
+// Multiple values for the same field are combined with AND, matching Gmail's
+// behavior for repeated operators.
+type SearchCriteria struct {
+	From          []string
+	To            []string
+	Subject       []string
+	Labels        []string
+	Filenames     []string
+	After         string
+	Before        string
+	Larger        string
+	HasAttachment bool
+	Unread        bool
+}
+
+var sizePattern = regexp.MustCompile(`^(?i)(\d+)\s*([kmg]?)b?$`)
+
+// BuildQuery combines a free-form query with the structured criteria into a
+// single Gmail query string. Values are quoted where Gmail requires it.
+func (c SearchCriteria) BuildQuery(base string) (string, error) {
+	var terms []string
+	if base = strings.TrimSpace(base); base != "" {
+		terms = append(terms, base)
+	}
+
+	add := func(operator string, values []string) {
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				terms = append(terms, operator+":"+quoteQueryValue(v))
+			}
+		}
+	}
+	add("from", c.From)
+	add("to", c.To)
+	add("subject", c.Subject)
+	add("label", c.Labels)
+	add("filename", c.Filenames)
+
+	if c.After != "" {
+		d, err := normalizeQueryDate(c.After)
+		if err != nil {
+			return "", fmt.Errorf("invalid after date: %w", err)
+		}
+		terms = append(terms, "after:"+d)
+	}
+	if c.Before != "" {
+		d, err := normalizeQueryDate(c.Before)
+		if err != nil {
+			return "", fmt.Errorf("invalid before date: %w", err)
+		}
+		terms = append(terms, "before:"+d)
+	}
+	if c.Larger != "" {
+		size, err := normalizeQuerySize(c.Larger)
+		if err != nil {
+			return "", fmt.Errorf("invalid larger size: %w", err)
+		}
+		terms = append(terms, "larger:"+size)
+	}
+	if c.HasAttachment {
+		terms = append(terms, "has:attachment")
+	}
+	if c.Unread {
+		terms = append(terms, "is:unread")
+	}
+
+	// Group a free-form OR query so the added operators apply to every branch
+	if len(terms) > 1 && strings.Contains(" "+base+" ", " OR ") {
+		terms[0] = "(" + base + ")"
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+// quoteQueryValue wraps a value in double quotes when it contains characters
+// Gmail would otherwise treat as query syntax. Gmail has no escape for a
+// double quote inside a quoted phrase, so embedded quotes are dropped.
+func quoteQueryValue(v string) string {
+	v = strings.ReplaceAll(v, `"`, "")
+	if v == "" || strings.ContainsAny(v, " \t(){}:") || strings.HasPrefix(v, "-") {
+		return `"` + v + `"`
+	}
+	return v
+}
+
+// normalizeQueryDate accepts YYYY-MM-DD or YYYY/MM/DD and returns Gmail's YYYY/MM/DD form
+func normalizeQueryDate(s string) (string, error) {
+	for _, layout := range []string{"2006/01/02", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t.Format("2006/01/02"), nil
+		}
+	}
+	return "", fmt.Errorf("%q is not a YYYY-MM-DD date", s)
+}
+
+// normalizeQuerySize accepts sizes like 500, 10K, 5MB and returns Gmail's form (e.g. 5M)
+func normalizeQuerySize(s string) (string, error) {
+	m := sizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", fmt.Errorf("%q is not a size like 500K or 5M", s)
+	}
+	return m[1] + strings.ToUpper(m[2]), nil
+}
@@ -0,0 +1,85 @@
+package gmail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCriteriaBuildQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria SearchCriteria
+		base     string
+		expected string
+	}{
+		{"base only", SearchCriteria{}, "is:starred", "is:starred"},
+		{"empty", SearchCriteria{}, "", ""},
+		{"simple from", SearchCriteria{From: []string{"alice@example.com"}}, "", "from:alice@example.com"},
+		{"quotes spaces", SearchCriteria{Subject: []string{"weekly report"}}, "", `subject:"weekly report"`},
+		{"drops embedded quotes", SearchCriteria{Subject: []string{`say "hi" now`}}, "", `subject:"say hi now"`},
+		{"quotes leading dash", SearchCriteria{Subject: []string{"-draft"}}, "", `subject:"-draft"`},
+		{"quotes parentheses", SearchCriteria{Labels: []string{"a(b)"}}, "", `label:"a(b)"`},
+		{"repeated values", SearchCriteria{To: []string{"a@x.com", "b@x.com"}}, "", "to:a@x.com to:b@x.com"},
+		{"groups OR base query", SearchCriteria{Unread: true}, "from:a OR from:b", "(from:a OR from:b) is:unread"},
+		{"leaves lone OR query", SearchCriteria{}, "from:a OR from:b", "from:a OR from:b"},
+		{"skips blank values", SearchCriteria{From: []string{"  "}}, "", ""},
+		{
+			name: "combines with base query",
+			criteria: SearchCriteria{
+				From:          []string{"billing@"},
+				Filenames:     []string{"invoice.pdf"},
+				After:         "2024-01-01",
+				Before:        "2024/02/01",
+				Larger:        "5mb",
+				HasAttachment: true,
+				Unread:        true,
+			},
+			base:     "in:inbox",
+			expected: "in:inbox from:billing@ filename:invoice.pdf after:2024/01/01 before:2024/02/01 larger:5M has:attachment is:unread",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.criteria.BuildQuery(tt.base)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestSearchCriteriaBuildQueryErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria SearchCriteria
+	}{
+		{"invalid after", SearchCriteria{After: "yesterday"}},
+		{"invalid before", SearchCriteria{Before: "2024-13-01"}},
+		{"invalid size", SearchCriteria{Larger: "big"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.criteria.BuildQuery("")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNormalizeQuerySize(t *testing.T) {
+	tests := map[string]string{
+		"500":   "500",
+		"10K":   "10K",
+		"10kb":  "10K",
+		"5 MB":  "5M",
+		"1G":    "1G",
+		"2048b": "2048",
+	}
+	for input, expected := range tests {
+		result, err := normalizeQuerySize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, result, input)
+	}
+}
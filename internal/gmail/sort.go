@@ -0,0 +1,84 @@
+package gmail
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKeys lists the accepted keys for SortMessages
+var SortKeys = []string{"date", "from", "subject", "size"}
+
+// ValidateSortKey returns an error if key is not one of SortKeys
+func ValidateSortKey(key string) error {
+	for _, k := range SortKeys {
+		if key == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sort key %q: must be one of %s", key, strings.Join(SortKeys, ", "))
+}
+
+// SortMessages orders messages in place by the given key. Dates use Gmail's
+// internalDate and sizes use sizeEstimate. Ties are broken by message ID so
+// the result is deterministic regardless of the order Gmail returned.
+func SortMessages(messages []*Message, key string, reverse bool) error {
+	if err := ValidateSortKey(key); err != nil {
+		return err
+	}
+
+	var compare func(a, b *Message) int
+	switch key {
+	case "date":
+		compare = func(a, b *Message) int { return compareTimes(a.InternalDate, b.InternalDate) }
+	case "from":
+		compare = func(a, b *Message) int { return compareFold(a.From, b.From) }
+	case "subject":
+		compare = func(a, b *Message) int { return compareFold(a.Subject, b.Subject) }
+	case "size":
+		compare = func(a, b *Message) int { return compareInt64(a.SizeEstimate, b.SizeEstimate) }
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		c := compare(messages[i], messages[j])
+		if c == 0 {
+			c = strings.Compare(messages[i].ID, messages[j].ID)
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	return nil
+}
+
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b)))
+}
+
+// compareTimes orders unknown times before known ones
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,71 @@
+package gmail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func sortFixture() []*Message {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*Message{
+		{ID: "b", From: "carol@example.com", Subject: "beta", SizeEstimate: 300, InternalDate: timePtr(base.Add(2 * time.Hour))},
+		{ID: "a", From: "Alice@example.com", Subject: "Gamma", SizeEstimate: 100, InternalDate: timePtr(base.Add(3 * time.Hour))},
+		{ID: "c", From: "bob@example.com", Subject: "alpha", SizeEstimate: 200, InternalDate: timePtr(base.Add(1 * time.Hour))},
+		{ID: "d", From: "bob@example.com", Subject: "alpha", SizeEstimate: 200, InternalDate: timePtr(base.Add(1 * time.Hour))},
+	}
+}
+
+func messageIDs(messages []*Message) []string {
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestSortMessages(t *testing.T) {
+	tests := []struct {
+		key      string
+		reverse  bool
+		expected []string
+	}{
+		{"date", false, []string{"c", "d", "b", "a"}},
+		{"date", true, []string{"a", "b", "d", "c"}},
+		{"from", false, []string{"a", "c", "d", "b"}},
+		{"subject", false, []string{"c", "d", "b", "a"}},
+		{"size", false, []string{"a", "c", "d", "b"}},
+		{"size", true, []string{"b", "d", "c", "a"}},
+	}
+
+	for _, tt := range tests {
+		name := tt.key
+		if tt.reverse {
+			name += " reversed"
+		}
+		t.Run(name, func(t *testing.T) {
+			messages := sortFixture()
+			require.NoError(t, SortMessages(messages, tt.key, tt.reverse))
+			assert.Equal(t, tt.expected, messageIDs(messages))
+		})
+	}
+
+	t.Run("order is independent of input order", func(t *testing.T) {
+		messages := sortFixture()
+		messages[2], messages[3] = messages[3], messages[2]
+		require.NoError(t, SortMessages(messages, "date", false))
+		assert.Equal(t, []string{"c", "d", "b", "a"}, messageIDs(messages))
+	})
+
+	t.Run("rejects unknown key", func(t *testing.T) {
+		assert.Error(t, SortMessages(sortFixture(), "priority", false))
+	})
+}
+
+func TestParseMessageSizeEstimate(t *testing.T) {
+	msg := parseMessage(&gmail.Message{Id: "msg123", SizeEstimate: 4096}, false, nil)
+	assert.Equal(t, int64(4096), msg.SizeEstimate)
+}
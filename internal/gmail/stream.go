@@ -0,0 +1,289 @@
+package gmail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/api/googleapi"
+)
+
+// StreamAttachment downloads an attachment and writes its decoded content to
+// w, returning the number of bytes written. Unlike DownloadAttachment, the
+// base64url data is decoded as it arrives, so the attachment is never held
+// in memory.
+func (c *Client) StreamAttachment(messageID, attachmentID string, w io.Writer) (int64, error) {
+	u := fmt.Sprintf("%sgmail/v1/users/%s/messages/%s/attachments/%s?alt=json&fields=data",
+		c.Service.BasePath, url.PathEscape(c.UserID), url.PathEscape(messageID), url.PathEscape(attachmentID))
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return 0, fmt.Errorf("failed to download attachment: %w", err)
+	}
+
+	data := &base64Padder{r: newJSONFieldReader(resp.Body, "data")}
+	n, err := io.Copy(w, base64.NewDecoder(base64.URLEncoding, data))
+	if err != nil {
+		return n, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	return n, nil
+}
+
+// jsonFieldReader reads the value of a string field of a top-level JSON
+// object without buffering the whole document or the whole value
+type jsonFieldReader struct {
+	r       *bufio.Reader
+	field   string
+	started bool
+	done    bool
+}
+
+func newJSONFieldReader(r io.Reader, field string) io.Reader {
+	return &jsonFieldReader{r: bufio.NewReaderSize(r, 64*1024), field: field}
+}
+
+func (j *jsonFieldReader) Read(p []byte) (int, error) {
+	if !j.started {
+		j.started = true
+		if err := j.seek(); err != nil {
+			j.done = true
+			return 0, err
+		}
+	}
+	if j.done {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) {
+		b, err := j.readByte()
+		if err != nil {
+			return n, err
+		}
+		switch b {
+		case '"':
+			j.done = true
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		case '\\':
+			if b, err = j.readEscape(); err != nil {
+				return n, err
+			}
+		}
+		p[n] = b
+		n++
+	}
+	return n, nil
+}
+
+// seek advances to the first byte of the field's string value
+func (j *jsonFieldReader) seek() error {
+	if err := j.expect('{'); err != nil {
+		return err
+	}
+	for {
+		b, err := j.next()
+		if err != nil {
+			return err
+		}
+		switch b {
+		case '}':
+			return fmt.Errorf("response has no %q field", j.field)
+		case ',':
+			continue
+		case '"':
+		default:
+			return fmt.Errorf("invalid JSON response: unexpected %q", b)
+		}
+
+		key, err := j.readString()
+		if err != nil {
+			return err
+		}
+		if err := j.expect(':'); err != nil {
+			return err
+		}
+		if key == j.field {
+			if err := j.expect('"'); err != nil {
+				return fmt.Errorf("%q field is not a string", j.field)
+			}
+			return nil
+		}
+		if err := j.skipValue(); err != nil {
+			return err
+		}
+	}
+}
+
+// readString reads the rest of a string whose opening quote was consumed.
+// Escapes are kept undecoded, which is enough to compare field names.
+func (j *jsonFieldReader) readString() (string, error) {
+	var s []byte
+	for {
+		b, err := j.readByte()
+		if err != nil {
+			return "", err
+		}
+		if b == '"' {
+			return string(s), nil
+		}
+		if b == '\\' {
+			if b, err = j.readByte(); err != nil {
+				return "", err
+			}
+		}
+		s = append(s, b)
+	}
+}
+
+// skipValue skips a value of any type
+func (j *jsonFieldReader) skipValue() error {
+	b, err := j.next()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case '"':
+		_, err := j.readString()
+		return err
+	case '{', '[':
+		for depth := 1; depth > 0; {
+			if b, err = j.readByte(); err != nil {
+				return err
+			}
+			switch b {
+			case '"':
+				if _, err := j.readString(); err != nil {
+					return err
+				}
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		return nil
+	}
+
+	// Numbers and literals end at the next delimiter
+	for {
+		if b, err = j.readByte(); err != nil {
+			return err
+		}
+		if b == ',' || b == '}' || b == ']' || isJSONSpace(b) {
+			return j.r.UnreadByte()
+		}
+	}
+}
+
+// readEscape decodes an escape sequence inside the value. Base64 text only
+// contains ASCII, so anything else is reported as an error.
+func (j *jsonFieldReader) readEscape() (byte, error) {
+	b, err := j.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case '"', '\\', '/':
+		return b, nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 'u':
+		var hex [4]byte
+		if _, err := io.ReadFull(j.r, hex[:]); err != nil {
+			return 0, fmt.Errorf("invalid JSON response: %w", err)
+		}
+		var r rune
+		if _, err := fmt.Sscanf(string(hex[:]), "%04x", &r); err != nil || r >= 0x80 {
+			return 0, fmt.Errorf("invalid escape \\u%s in %q field", hex[:], j.field)
+		}
+		return byte(r), nil
+	}
+	return 0, fmt.Errorf("invalid escape \\%c in %q field", b, j.field)
+}
+
+func (j *jsonFieldReader) expect(want byte) error {
+	b, err := j.next()
+	if err != nil {
+		return err
+	}
+	if b != want {
+		return fmt.Errorf("invalid JSON response: expected %q, got %q", want, b)
+	}
+	return nil
+}
+
+// next returns the next byte that is not whitespace
+func (j *jsonFieldReader) next() (byte, error) {
+	for {
+		b, err := j.readByte()
+		if err != nil || !isJSONSpace(b) {
+			return b, err
+		}
+	}
+}
+
+func (j *jsonFieldReader) readByte() (byte, error) {
+	b, err := j.r.ReadByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// base64Padder appends the '=' padding missing from unpadded base64 input,
+// which the streaming decoder requires
+type base64Padder struct {
+	r   io.Reader
+	n   int
+	pad int
+	eof bool
+}
+
+func (p *base64Padder) Read(b []byte) (int, error) {
+	if !p.eof {
+		n, err := p.r.Read(b)
+		for _, c := range b[:n] {
+			if c != '\r' && c != '\n' {
+				p.n++
+			}
+		}
+		if err != io.EOF {
+			return n, err
+		}
+		p.eof = true
+		p.pad = (4 - p.n%4) % 4
+		if n > 0 {
+			return n, nil
+		}
+	}
+
+	if p.pad == 0 {
+		return 0, io.EOF
+	}
+	k := min(p.pad, len(b))
+	for i := range b[:k] {
+		b[i] = '='
+	}
+	p.pad -= k
+	return k, nil
+}
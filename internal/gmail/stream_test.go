@@ -0,0 +1,92 @@
+package gmail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// newTestClient returns a client whose requests are answered by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	svc, err := gmailapi.NewService(context.Background(),
+		option.WithHTTPClient(srv.Client()), option.WithEndpoint(srv.URL+"/"))
+	require.NoError(t, err)
+	return &Client{Service: svc, UserID: "me", httpClient: srv.Client()}
+}
+
+func TestStreamAttachment(t *testing.T) {
+	content := bytes.Repeat([]byte("attachment data \x00\xff "), 5000)
+
+	t.Run("decodes the data field while streaming", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/gmail/v1/users/me/messages/msg1/attachments/att1", r.URL.Path)
+			assert.Equal(t, "data", r.URL.Query().Get("fields"))
+			_, _ = io.WriteString(w, `{"size": 100000, "extra": {"a": ["}", 1]}, "data": "`+
+				base64.URLEncoding.EncodeToString(content)+`"}`)
+		})
+
+		var buf bytes.Buffer
+		n, err := client.StreamAttachment("msg1", "att1", &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.Equal(t, content, buf.Bytes())
+	})
+
+	t.Run("accepts unpadded and escaped data", func(t *testing.T) {
+		encoded := base64.URLEncoding.EncodeToString([]byte("hello"))
+		require.True(t, strings.HasSuffix(encoded, "="))
+		escaped := strings.ReplaceAll(encoded, "=", `\u003d`)
+
+		for _, data := range []string{strings.TrimRight(encoded, "="), escaped} {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, `{"data":"`+data+`"}`)
+			})
+			var buf bytes.Buffer
+			_, err := client.StreamAttachment("msg1", "att1", &buf)
+			require.NoError(t, err, data)
+			assert.Equal(t, "hello", buf.String())
+		}
+	})
+
+	t.Run("reports API errors", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error": {"code": 404, "message": "Requested entity was not found."}}`)
+		})
+		_, err := client.StreamAttachment("msg1", "att1", io.Discard)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to download attachment")
+		assert.Contains(t, err.Error(), "Requested entity was not found.")
+	})
+
+	t.Run("reports a missing data field", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"size": 0}`)
+		})
+		_, err := client.StreamAttachment("msg1", "att1", io.Discard)
+		assert.ErrorContains(t, err, `no "data" field`)
+	})
+
+	t.Run("reports a truncated response", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"data": "aGVsbG`)
+		})
+		_, err := client.StreamAttachment("msg1", "att1", io.Discard)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
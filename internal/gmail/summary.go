@@ -0,0 +1,121 @@
+package gmail
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Participant is someone who sent or received a message in a thread
+type Participant struct {
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email"`
+	Messages int    `json:"messages"`
+}
+
+// TimelineEntry is one message in a thread summary
+type TimelineEntry struct {
+	ID      string     `json:"id"`
+	Date    *time.Time `json:"date,omitempty"`
+	From    Address    `json:"from"`
+	Snippet string     `json:"snippet"`
+}
+
+// ThreadSummary is an overview of a thread
+type ThreadSummary struct {
+	ThreadID      string          `json:"threadId"`
+	Subject       string          `json:"subject"`
+	MessageCount  int             `json:"messageCount"`
+	Participants  []Participant   `json:"participants"`
+	FirstActivity time.Time       `json:"firstActivity"`
+	LastActivity  time.Time       `json:"lastActivity"`
+	Attachments   int             `json:"attachments"`
+	Labels        []string        `json:"labels,omitempty"`
+	Timeline      []TimelineEntry `json:"timeline"`
+}
+
+// SummarizeThread builds an overview of a thread from its messages.
+// Participants are everyone in From, To and Cc; Messages counts the messages
+// each one sent, and participants are ordered by that count, then by first
+// appearance. Attachments counts regular attachments, not inline images.
+func SummarizeThread(messages []*Message) *ThreadSummary {
+	s := &ThreadSummary{MessageCount: len(messages), Participants: []Participant{}, Timeline: []TimelineEntry{}}
+	if len(messages) == 0 {
+		return s
+	}
+	s.ThreadID = messages[0].ThreadID
+	s.Subject = messages[0].Subject
+
+	index := make(map[string]int)
+	addParticipant := func(a Address, sent bool) {
+		key := strings.ToLower(a.Email)
+		if key == "" {
+			return
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(s.Participants)
+			index[key] = i
+			s.Participants = append(s.Participants, Participant{Email: a.Email})
+		}
+		if s.Participants[i].Name == "" {
+			s.Participants[i].Name = a.Name
+		}
+		if sent {
+			s.Participants[i].Messages++
+		}
+	}
+
+	seenLabels := make(map[string]bool)
+	for _, msg := range messages {
+		for _, a := range msg.FromAddresses {
+			addParticipant(a, true)
+		}
+		for _, list := range [][]Address{msg.ToAddresses, msg.CcAddresses} {
+			for _, a := range list {
+				addParticipant(a, false)
+			}
+		}
+
+		if msg.DateParsed != nil {
+			if s.FirstActivity.IsZero() || msg.DateParsed.Before(s.FirstActivity) {
+				s.FirstActivity = *msg.DateParsed
+			}
+			if msg.DateParsed.After(s.LastActivity) {
+				s.LastActivity = *msg.DateParsed
+			}
+		}
+
+		for _, att := range msg.Attachments {
+			if !att.IsInline {
+				s.Attachments++
+			}
+		}
+
+		for _, label := range msg.Labels {
+			if !seenLabels[label] {
+				seenLabels[label] = true
+				s.Labels = append(s.Labels, label)
+			}
+		}
+
+		entry := TimelineEntry{
+			ID:      msg.ID,
+			Date:    msg.DateParsed,
+			Snippet: strings.Join(strings.Fields(html.UnescapeString(msg.Snippet)), " "),
+		}
+		if len(msg.FromAddresses) > 0 {
+			entry.From = msg.FromAddresses[0]
+		} else {
+			entry.From = Address{Name: msg.From}
+		}
+		s.Timeline = append(s.Timeline, entry)
+	}
+
+	sort.SliceStable(s.Participants, func(i, j int) bool {
+		return s.Participants[i].Messages > s.Participants[j].Messages
+	})
+
+	return s
+}
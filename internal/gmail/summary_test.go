@@ -0,0 +1,60 @@
+package gmail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeThread(t *testing.T) {
+	alice := Address{Name: "Alice", Email: "alice@example.com"}
+	bob := Address{Email: "bob@example.com"}
+	carol := Address{Name: "Carol", Email: "carol@example.com"}
+	at := func(hour int) time.Time { return time.Date(2024, 1, 15, hour, 0, 0, 0, time.UTC) }
+
+	messages := []*Message{
+		{
+			ID: "m1", ThreadID: "t1", Subject: "Plan", DateParsed: timePtr(at(9)), Snippet: "Shall we   meet?",
+			FromAddresses: []Address{alice}, ToAddresses: []Address{bob}, CcAddresses: []Address{carol},
+			Labels:      []string{"Work"},
+			Attachments: []*Attachment{{Filename: "agenda.pdf"}, {Filename: "logo.png", IsInline: true}},
+		},
+		{
+			ID: "m2", ThreadID: "t1", Subject: "Re: Plan", DateParsed: timePtr(at(11)), Snippet: "I don&#39;t mind",
+			FromAddresses: []Address{{Email: "BOB@example.com"}}, ToAddresses: []Address{alice},
+			Labels: []string{"Work", "Project"},
+		},
+		{
+			ID: "m3", ThreadID: "t1", Subject: "Re: Plan", DateParsed: timePtr(at(10)),
+			FromAddresses: []Address{bob}, ToAddresses: []Address{alice},
+			Attachments: []*Attachment{{Filename: "notes.txt"}},
+		},
+	}
+
+	s := SummarizeThread(messages)
+	assert.Equal(t, "t1", s.ThreadID)
+	assert.Equal(t, "Plan", s.Subject)
+	assert.Equal(t, 3, s.MessageCount)
+	assert.Equal(t, []Participant{
+		{Email: "bob@example.com", Messages: 2},
+		{Name: "Alice", Email: "alice@example.com", Messages: 1},
+		{Name: "Carol", Email: "carol@example.com", Messages: 0},
+	}, s.Participants)
+	assert.Equal(t, at(9), s.FirstActivity)
+	assert.Equal(t, at(11), s.LastActivity)
+	assert.Equal(t, 2, s.Attachments)
+	assert.Equal(t, []string{"Work", "Project"}, s.Labels)
+
+	require.Len(t, s.Timeline, 3)
+	assert.Equal(t, TimelineEntry{ID: "m1", Date: timePtr(at(9)), From: alice, Snippet: "Shall we meet?"}, s.Timeline[0])
+	assert.Equal(t, "I don't mind", s.Timeline[1].Snippet)
+}
+
+func TestSummarizeThreadEmpty(t *testing.T) {
+	s := SummarizeThread(nil)
+	assert.Equal(t, 0, s.MessageCount)
+	assert.Empty(t, s.Participants)
+	assert.Empty(t, s.Timeline)
+}
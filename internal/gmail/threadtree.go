@@ -0,0 +1,87 @@
+package gmail
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ThreadNode is a message in a reply tree. Its JSON form is the message
+// with its direct replies nested under "replies".
+type ThreadNode struct {
+	*Message
+	Replies []*ThreadNode `json:"replies,omitempty"`
+}
+
+// msgIDPattern matches a bracketed message ID in In-Reply-To or References
+var msgIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+// BuildThreadTree arranges the messages of a thread into reply trees using
+// the Message-ID, In-Reply-To and References headers. A message's parent is
+// the message named by In-Reply-To or, failing that, the closest ancestor
+// from References that is part of the thread. Messages without a known
+// parent become roots. Roots and replies keep the order of messages.
+func BuildThreadTree(messages []*Message) []*ThreadNode {
+	nodes := make([]*ThreadNode, len(messages))
+	byID := make(map[string]*ThreadNode)
+	for i, msg := range messages {
+		nodes[i] = &ThreadNode{Message: msg}
+		if id := normalizeMessageID(msg.MessageID); id != "" {
+			if _, dup := byID[id]; !dup {
+				byID[id] = nodes[i]
+			}
+		}
+	}
+
+	parents := make(map[*ThreadNode]*ThreadNode)
+	for _, node := range nodes {
+		for _, id := range parentCandidates(node.Message) {
+			parent, ok := byID[id]
+			if !ok || parent == node || isAncestor(node, parent, parents) {
+				continue
+			}
+			parents[node] = parent
+			break
+		}
+	}
+
+	var roots []*ThreadNode
+	for _, node := range nodes {
+		if parent, ok := parents[node]; ok {
+			parent.Replies = append(parent.Replies, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// parentCandidates returns the normalized IDs a message may reply to, most
+// likely parent first
+func parentCandidates(msg *Message) []string {
+	var ids []string
+	for _, id := range msgIDPattern.FindAllString(msg.InReplyTo, -1) {
+		ids = append(ids, normalizeMessageID(id))
+	}
+	for i := len(msg.References) - 1; i >= 0; i-- {
+		if id := normalizeMessageID(msg.References[i]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// isAncestor reports whether node is already an ancestor of candidate,
+// which would make candidate's adoption of node a cycle
+func isAncestor(node, candidate *ThreadNode, parents map[*ThreadNode]*ThreadNode) bool {
+	for n := candidate; n != nil; n = parents[n] {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMessageID strips whitespace and angle brackets from a message ID
+func normalizeMessageID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
@@ -0,0 +1,77 @@
+package gmail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildThreadTree(t *testing.T) {
+	root := &Message{ID: "1", MessageID: "<a@x>"}
+	replyA := &Message{ID: "2", MessageID: "<b@x>", InReplyTo: "<a@x>", References: []string{"<a@x>"}}
+	replyB := &Message{ID: "3", MessageID: "<c@x>", InReplyTo: "<a@x>", References: []string{"<a@x>"}}
+	nested := &Message{ID: "4", MessageID: "<d@x>", InReplyTo: "<b@x>", References: []string{"<a@x>", "<b@x>"}}
+	// In-Reply-To points outside the thread, References still finds the parent
+	viaRefs := &Message{ID: "5", MessageID: "<e@x>", InReplyTo: "<missing@x>", References: []string{"<a@x>", "<c@x>", "<missing@x>"}}
+	orphan := &Message{ID: "6", MessageID: "<f@x>", InReplyTo: "<elsewhere@x>"}
+
+	roots := BuildThreadTree([]*Message{root, replyA, replyB, nested, viaRefs, orphan})
+	require.Len(t, roots, 2)
+	assert.Equal(t, "1", roots[0].ID)
+	assert.Equal(t, "6", roots[1].ID)
+
+	require.Len(t, roots[0].Replies, 2)
+	assert.Equal(t, "2", roots[0].Replies[0].ID)
+	assert.Equal(t, "3", roots[0].Replies[1].ID)
+	require.Len(t, roots[0].Replies[0].Replies, 1)
+	assert.Equal(t, "4", roots[0].Replies[0].Replies[0].ID)
+	require.Len(t, roots[0].Replies[1].Replies, 1)
+	assert.Equal(t, "5", roots[0].Replies[1].Replies[0].ID)
+}
+
+func TestBuildThreadTreeEdgeCases(t *testing.T) {
+	t.Run("no message ids", func(t *testing.T) {
+		roots := BuildThreadTree([]*Message{{ID: "1"}, {ID: "2"}})
+		assert.Len(t, roots, 2)
+	})
+
+	t.Run("self reference", func(t *testing.T) {
+		roots := BuildThreadTree([]*Message{{ID: "1", MessageID: "<a@x>", InReplyTo: "<a@x>"}})
+		require.Len(t, roots, 1)
+		assert.Empty(t, roots[0].Replies)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		a := &Message{ID: "1", MessageID: "<a@x>", InReplyTo: "<b@x>"}
+		b := &Message{ID: "2", MessageID: "<b@x>", InReplyTo: "<a@x>"}
+		roots := BuildThreadTree([]*Message{a, b})
+		require.Len(t, roots, 1)
+		assert.Equal(t, "2", roots[0].ID)
+		require.Len(t, roots[0].Replies, 1)
+		assert.Equal(t, "1", roots[0].Replies[0].ID)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, BuildThreadTree(nil))
+	})
+}
+
+func TestThreadNodeJSON(t *testing.T) {
+	roots := BuildThreadTree([]*Message{
+		{ID: "1", MessageID: "<a@x>"},
+		{ID: "2", InReplyTo: "<a@x>"},
+	})
+
+	data, err := json.Marshal(roots[0])
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "1", decoded["id"])
+	replies, ok := decoded["replies"].([]any)
+	require.True(t, ok)
+	require.Len(t, replies, 1)
+	assert.Equal(t, "2", replies[0].(map[string]any)["id"])
+}
@@ -0,0 +1,282 @@
+package htmlconv
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MarkdownOptions customizes ToMarkdown
+type MarkdownOptions struct {
+	// ImageRef rewrites an image source, e.g. to point cid: references at
+	// attachments. Returning "" keeps the original source.
+	ImageRef func(src string) string
+}
+
+// ToMarkdown converts an HTML document or fragment to Markdown. Headings,
+// emphasis, links, lists, blockquotes, code and images map to their Markdown
+// forms. Tables holding only inline content become pipe tables; layout
+// tables, which most HTML email uses for positioning, are flattened.
+func ToMarkdown(src string, opts MarkdownOptions) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return src
+	}
+
+	r := &renderer{markdown: true, imageRef: opts.ImageRef}
+	r.render(doc)
+	return r.finish()
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// renderMarkdown handles elements whose Markdown form differs from plain
+// text. It reports whether n was rendered.
+func (r *renderer) renderMarkdown(n *html.Node) bool {
+	switch a := n.DataAtom; a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.block(2)
+		r.write(strings.Repeat("#", headingLevels[a]) + " ")
+		r.glue = true
+		r.children(n)
+		r.block(2)
+	case atom.B, atom.Strong:
+		r.emphasis(n, "**")
+	case atom.I, atom.Em:
+		r.emphasis(n, "_")
+	case atom.S, atom.Del, atom.Strike:
+		r.emphasis(n, "~~")
+	case atom.Code:
+		if r.pre > 0 {
+			return false
+		}
+		r.code++
+		r.emphasis(n, "`")
+		r.code--
+	case atom.Pre:
+		r.block(2)
+		r.write("```")
+		r.block(1)
+		r.pre++
+		r.code++
+		r.children(n)
+		r.code--
+		r.pre--
+		r.block(1)
+		r.write("```")
+		r.block(2)
+	case atom.A:
+		r.markdownLink(n)
+	case atom.Img:
+		r.markdownImage(n)
+	case atom.Table:
+		if !isDataTable(n) {
+			return false
+		}
+		r.markdownTable(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// emphasis wraps the children of n in mark, dropping the marks if the
+// element renders no text
+func (r *renderer) emphasis(n *html.Node, mark string) {
+	r.write(mark)
+	start := r.out.Len()
+	r.glue = true
+	r.children(n)
+
+	if r.out.Len() == start {
+		r.out.Truncate(start - len(mark))
+		r.glue = false
+		if b := r.out.Bytes(); len(b) > 0 && b[len(b)-1] == ' ' {
+			r.out.Truncate(len(b) - 1)
+			r.space = true
+		}
+		return
+	}
+
+	// Closing marks attach to the last text, before any pending space or break
+	r.out.WriteString(mark)
+}
+
+func (r *renderer) markdownLink(n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	if !isFootnoteLink(href) {
+		r.children(n)
+		return
+	}
+
+	r.write("[")
+	start := r.out.Len()
+	r.glue = true
+	r.children(n)
+
+	if len(bytes.TrimSpace(r.out.Bytes()[start:])) == 0 {
+		r.out.Truncate(start - 1)
+		r.glue = false
+		return
+	}
+	r.out.WriteString("](" + escapeURL(href) + ")")
+}
+
+func (r *renderer) markdownImage(n *html.Node) {
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" || isTrackingPixel(n) {
+		return
+	}
+	if r.imageRef != nil {
+		if ref := r.imageRef(src); ref != "" {
+			src = ref
+		}
+	}
+	alt := escapeMarkdown(strings.Join(strings.Fields(attr(n, "alt")), " "))
+	r.write("![" + alt + "](" + escapeURL(src) + ")")
+}
+
+// markdownTable renders a data table as a pipe table, using the first row as
+// the header
+func (r *renderer) markdownTable(n *html.Node) {
+	var rows [][]string
+	var caption string
+	width := 0
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Caption:
+				caption = r.inlineMarkdown(c)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row = append(row, strings.ReplaceAll(r.inlineMarkdown(cell), "|", `\|`))
+					}
+				}
+				if len(row) > width {
+					width = len(row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+
+	if width == 0 {
+		return
+	}
+
+	r.block(2)
+	if caption != "" {
+		r.write(caption)
+		r.block(2)
+	}
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		r.block(1)
+		r.write("| " + strings.Join(row, " | ") + " |")
+		if i == 0 {
+			r.block(1)
+			r.write("|" + strings.Repeat(" --- |", width))
+		}
+	}
+	r.block(2)
+}
+
+// inlineMarkdown renders the children of n as a single line of Markdown
+func (r *renderer) inlineMarkdown(n *html.Node) string {
+	sub := &renderer{markdown: true, imageRef: r.imageRef}
+	sub.children(n)
+
+	var parts []string
+	for _, line := range strings.Split(sub.finish(), "\n") {
+		if line = strings.TrimSpace(strings.TrimSuffix(line, `\`)); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isDataTable reports whether a table holds only inline content, so that it
+// can be rendered as a Markdown pipe table
+func isDataTable(table *html.Node) bool {
+	var inline func(*html.Node) bool
+	inline = func(n *html.Node) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch a := c.DataAtom; {
+			case a == atom.Table, a == atom.Ul, a == atom.Ol, a == atom.Pre,
+				a == atom.Blockquote, a == atom.Hr, blocks[a]:
+				return false
+			}
+			if !inline(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return inline(table)
+}
+
+// isTrackingPixel reports whether an image is a 1x1 or invisible beacon
+func isTrackingPixel(n *html.Node) bool {
+	for _, key := range []string{"width", "height"} {
+		if v := strings.TrimSuffix(strings.TrimSpace(attr(n, key)), "px"); v == "0" || v == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeMarkdown escapes characters that would otherwise start Markdown
+// syntax. Underscores inside words are left alone, as CommonMark does not
+// treat them as emphasis.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for i, c := range s {
+		switch c {
+		case '\\', '*', '`', '[', ']':
+			b.WriteByte('\\')
+		case '_':
+			if !(isWordRune(lastRune(s[:i])) && isWordRune(firstRune(s[i+1:]))) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// escapeURL encodes the characters that would end a Markdown link target
+func escapeURL(s string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(s)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
@@ -0,0 +1,98 @@
+package htmlconv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "headings and paragraphs",
+			html:     "<h1>Title</h1><p>Intro text.</p><h3>Sub</h3>",
+			expected: "# Title\n\nIntro text.\n\n### Sub",
+		},
+		{
+			name:     "emphasis",
+			html:     "<p>This is <b> bold </b>, <em>italic</em> and <del>gone</del>.</p>",
+			expected: "This is **bold** , _italic_ and ~~gone~~.",
+		},
+		{
+			name:     "empty emphasis dropped",
+			html:     "<p>a <strong></strong> b</p>",
+			expected: "a b",
+		},
+		{
+			name:     "links",
+			html:     `<p>See <a href="https://example.com/a b">the <i>docs</i></a> or <a href="#x">here</a>.</p>`,
+			expected: "See [the _docs_](https://example.com/a%20b) or here.",
+		},
+		{
+			name:     "lists",
+			html:     "<ul><li>One</li><li>Two<ol><li>Sub</li></ol></li></ul>",
+			expected: "- One\n- Two\n  1. Sub",
+		},
+		{
+			name:     "data table",
+			html:     "<table><tr><th>Item</th><th>Qty</th></tr><tr><td><b>Apple</b></td><td>2</td></tr><tr><td>A|B</td></tr></table>",
+			expected: "| Item | Qty |\n| --- | --- |\n| **Apple** | 2 |\n| A\\|B |  |",
+		},
+		{
+			name:     "layout table is flattened",
+			html:     "<table><tr><td><p>Left</p></td><td><p>Right</p></td></tr></table>",
+			expected: "Left\n\nRight",
+		},
+		{
+			name:     "images",
+			html:     `<p><img src="cid:logo@x" alt="ACME logo"> <img src="https://t.example/p.gif" width="1" height="1"><img src="https://example.com/a.png"></p>`,
+			expected: "![ACME logo](attachment:1.2) ![](https://example.com/a.png)",
+		},
+		{
+			name:     "code",
+			html:     "<p>Run <code>go test *</code></p><pre><code>x := 1\n  y()</code></pre>",
+			expected: "Run `go test *`\n\n```\nx := 1\n  y()\n```",
+		},
+		{
+			name:     "blockquote",
+			html:     "<blockquote><p>Quoted</p><p>More</p></blockquote>",
+			expected: "> Quoted\n>\n> More",
+		},
+		{
+			name:     "hard line breaks",
+			html:     "<p>Jane Doe<br>ACME Corp<br></p><p>Next</p>",
+			expected: "Jane Doe\\\nACME Corp\n\nNext",
+		},
+		{
+			name:     "escapes markdown syntax",
+			html:     "<p>*not bold* [x] snake_case _lead</p>",
+			expected: "\\*not bold\\* \\[x\\] snake_case \\_lead",
+		},
+	}
+
+	opts := MarkdownOptions{ImageRef: func(src string) string {
+		if strings.HasPrefix(src, "cid:") {
+			return "attachment:1.2"
+		}
+		return ""
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToMarkdown(tt.html, opts))
+		})
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	assert.Equal(t, "plain", escapeMarkdown("plain"))
+	assert.Equal(t, `a\\b`, escapeMarkdown(`a\b`))
+	assert.Equal(t, "foo_bar", escapeMarkdown("foo_bar"))
+	assert.Equal(t, `\_foo\_`, escapeMarkdown("_foo_"))
+	assert.Equal(t, "\\`x\\`", escapeMarkdown("`x`"))
+}
@@ -0,0 +1,415 @@
+// Package htmlconv renders HTML email bodies as readable plain text.
+package htmlconv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped elements never contribute text
+var skipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Title: true,
+	atom.Noscript: true, atom.Template: true, atom.Iframe: true, atom.Object: true,
+	atom.Svg: true, atom.Math: true,
+}
+
+// blocks start and end on their own line, separated from siblings by a blank line
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Header: true, atom.Footer: true, atom.Main: true, atom.Nav: true,
+	atom.Aside: true, atom.Address: true, atom.Figure: true, atom.Form: true,
+	atom.Fieldset: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Dl: true,
+	atom.Center: true, atom.Details: true, atom.Summary: true,
+}
+
+// lineBlocks start and end on their own line without a blank line
+var lineBlocks = map[atom.Atom]bool{
+	atom.Dt: true, atom.Dd: true, atom.Figcaption: true, atom.Caption: true,
+	atom.Tbody: true, atom.Thead: true, atom.Tfoot: true,
+}
+
+// ToText converts an HTML document or fragment to plain text. Block elements
+// are laid out on their own lines, lists are bulleted or numbered, table rows
+// become lines of cells separated by " | ", and link targets are collected
+// as numbered footnotes. Scripts, styles and hidden elements are dropped.
+func ToText(src string) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		// html.Parse only fails on reader errors, which a string reader never returns
+		return src
+	}
+
+	r := &renderer{linkIndex: make(map[string]int)}
+	r.render(doc)
+	return r.finish()
+}
+
+type listState struct {
+	ordered bool
+	next    int
+}
+
+// renderer lays out an HTML tree as plain text or, with markdown set, as
+// Markdown. Output is written lazily so that pending line breaks, prefixes
+// and spaces are only emitted in front of actual text.
+type renderer struct {
+	out bytes.Buffer
+
+	markdown bool
+	imageRef func(src string) string
+
+	// prefixes are prepended to every line, e.g. list indentation and "> "
+	prefixes   []string
+	lastPrefix string
+	lists      []*listState
+
+	// newlines is the number of line breaks owed before the next text
+	newlines    int
+	atLineStart bool
+	space       bool
+	pre         int
+
+	// glue suppresses the pending space after an opening Markdown marker;
+	// hardBreak marks a pending newline that came from <br>
+	glue      bool
+	hardBreak bool
+	code      int
+
+	// cells counts the cells of each open table row; cellSep is set when the
+	// next text continues a row and should be separated from the previous cell
+	cells   []int
+	cellSep bool
+
+	links     []string
+	linkIndex map[string]int
+}
+
+func (r *renderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] || isHidden(n) {
+			return
+		}
+		if r.markdown && r.renderMarkdown(n) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	a := n.DataAtom
+	switch {
+	case a == atom.Br:
+		r.lineBreak()
+		return
+	case a == atom.Hr:
+		r.block(2)
+		r.write("---")
+		r.block(2)
+		return
+	case a == atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			r.text("[" + alt + "]")
+		}
+		return
+	case a == atom.Ul || a == atom.Ol:
+		r.list(n, a == atom.Ol)
+		return
+	case a == atom.Li:
+		r.listItem(n)
+		return
+	case a == atom.Table:
+		r.block(2)
+		r.children(n)
+		r.block(2)
+		return
+	case a == atom.Tr:
+		r.block(1)
+		r.cells = append(r.cells, 0)
+		r.children(n)
+		r.cells = r.cells[:len(r.cells)-1]
+		r.block(1)
+		return
+	case a == atom.Td || a == atom.Th:
+		if len(r.cells) > 0 {
+			if r.cells[len(r.cells)-1] > 0 {
+				r.cellSep = true
+			}
+			r.cells[len(r.cells)-1]++
+		}
+		r.children(n)
+		return
+	case a == atom.A:
+		r.link(n)
+		return
+	case a == atom.Blockquote:
+		r.block(2)
+		r.prefixes = append(r.prefixes, "> ")
+		r.children(n)
+		r.prefixes = r.prefixes[:len(r.prefixes)-1]
+		r.block(2)
+		return
+	case a == atom.Pre:
+		r.block(2)
+		r.pre++
+		r.children(n)
+		r.pre--
+		r.block(2)
+		return
+	case blocks[a]:
+		r.block(2)
+		r.children(n)
+		r.block(2)
+		return
+	case lineBlocks[a]:
+		r.block(1)
+		r.children(n)
+		r.block(1)
+		return
+	}
+
+	r.children(n)
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+func (r *renderer) list(n *html.Node, ordered bool) {
+	start := 1
+	if ordered {
+		if _, err := fmt.Sscanf(attr(n, "start"), "%d", &start); err != nil {
+			start = 1
+		}
+	}
+	// Nested lists only need a line break; top-level lists are blocks
+	gap := 2
+	if len(r.lists) > 0 {
+		gap = 1
+	}
+	r.block(gap)
+	r.lists = append(r.lists, &listState{ordered: ordered, next: start})
+	r.children(n)
+	r.lists = r.lists[:len(r.lists)-1]
+	r.block(gap)
+}
+
+func (r *renderer) listItem(n *html.Node) {
+	marker := "- "
+	if len(r.lists) > 0 {
+		if l := r.lists[len(r.lists)-1]; l.ordered {
+			marker = fmt.Sprintf("%d. ", l.next)
+			l.next++
+		}
+	}
+
+	r.block(1)
+	r.write(marker)
+	r.prefixes = append(r.prefixes, strings.Repeat(" ", len(marker)))
+	r.children(n)
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+	r.block(1)
+}
+
+func (r *renderer) link(n *html.Node) {
+	start := r.out.Len()
+	r.children(n)
+
+	href := strings.TrimSpace(attr(n, "href"))
+	if !isFootnoteLink(href) {
+		return
+	}
+
+	// Skip the footnote when the visible text already is the URL
+	visible := string(bytes.TrimSpace(r.out.Bytes()[start:]))
+	if visible == href || "mailto:"+visible == href || strings.TrimSuffix(href, "/") == visible {
+		return
+	}
+	if visible == "" {
+		return
+	}
+
+	num, ok := r.linkIndex[href]
+	if !ok {
+		r.links = append(r.links, href)
+		num = len(r.links)
+		r.linkIndex[href] = num
+	}
+	r.space = false
+	r.write(fmt.Sprintf(" [%d]", num))
+}
+
+// block ends the current line and requests n line breaks before the next text
+func (r *renderer) block(n int) {
+	r.space = false
+	r.hardBreak = false
+	if r.out.Len() == 0 {
+		return
+	}
+	if n > r.newlines {
+		r.newlines = n
+	}
+}
+
+func (r *renderer) lineBreak() {
+	r.space = false
+	// Leading breaks produce no output
+	if r.out.Len() == 0 {
+		return
+	}
+	r.newlines++
+	if r.newlines > 2 {
+		r.newlines = 2
+	}
+	r.hardBreak = r.markdown
+}
+
+// text writes a text node, collapsing whitespace outside <pre>
+func (r *renderer) text(s string) {
+	if r.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				r.newlines++
+			}
+			if line != "" {
+				r.write(line)
+			}
+		}
+		return
+	}
+
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	for i, word := range strings.Fields(s) {
+		if i > 0 || startsWithSpace(s) {
+			r.space = true
+		}
+		if r.markdown && r.code == 0 {
+			word = escapeMarkdown(word)
+		}
+		r.write(word)
+	}
+	if endsWithSpace(s) {
+		r.space = true
+	}
+}
+
+// write appends text, emitting owed line breaks, prefixes and spaces first
+func (r *renderer) write(s string) {
+	prefix := strings.Join(r.prefixes, "")
+	glue, hardBreak := r.glue, r.hardBreak
+	r.glue, r.hardBreak = false, false
+
+	// Cells laid out as blocks (common in email layout tables) need no separator
+	if r.cellSep {
+		r.cellSep = false
+		if r.newlines == 0 && !r.atLineStart && r.out.Len() > 0 {
+			r.out.WriteString(" | ")
+			r.space = false
+		}
+	}
+
+	if r.newlines > 0 && r.out.Len() > 0 {
+		// Blank lines keep the quote markers shared by the lines around them
+		blank := strings.TrimRight(commonPrefix(r.lastPrefix, prefix), " ")
+		for i := 1; i < r.newlines; i++ {
+			r.out.WriteString("\n" + blank)
+		}
+		if hardBreak && r.newlines == 1 {
+			r.out.WriteString("\\")
+		}
+		r.out.WriteString("\n")
+		r.atLineStart = true
+	}
+	r.newlines = 0
+
+	if r.out.Len() == 0 || r.atLineStart {
+		r.out.WriteString(prefix)
+		r.lastPrefix = prefix
+		r.atLineStart = false
+		r.space = false
+	}
+	if r.space && !glue {
+		r.out.WriteByte(' ')
+	}
+	r.space = false
+	r.out.WriteString(s)
+}
+
+func (r *renderer) finish() string {
+	text := r.out.String()
+
+	if len(r.links) > 0 {
+		var b strings.Builder
+		b.WriteString(strings.TrimRight(text, "\n"))
+		b.WriteString("\n\n")
+		for i, link := range r.links {
+			fmt.Fprintf(&b, "[%d] %s\n", i+1, link)
+		}
+		text = b.String()
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// isFootnoteLink reports whether a link target is worth listing
+func isFootnoteLink(href string) bool {
+	if href == "" || strings.HasPrefix(href, "#") {
+		return false
+	}
+	return !strings.HasPrefix(strings.ToLower(href), "javascript:")
+}
+
+// isHidden reports whether an element is hidden from readers, which is how
+// emails commonly embed preheader text and tracking content
+func isHidden(n *html.Node) bool {
+	if _, ok := attrValue(n, "hidden"); ok {
+		return true
+	}
+	style := strings.ToLower(strings.ReplaceAll(attr(n, "style"), " ", ""))
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n\f") != s
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s, " \t\r\n\f") != s
+}
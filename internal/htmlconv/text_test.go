@@ -0,0 +1,107 @@
+package htmlconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "paragraphs",
+			html:     "<p>Hello   there,</p>\n<p>Second\nparagraph.</p>",
+			expected: "Hello there,\n\nSecond paragraph.",
+		},
+		{
+			name:     "line breaks",
+			html:     "Line one<br>Line two<br/><br>Line four",
+			expected: "Line one\nLine two\n\nLine four",
+		},
+		{
+			name:     "inline elements keep spacing",
+			html:     "<p>This is <b>bold</b> and <i>italic</i>, ok?</p>",
+			expected: "This is bold and italic, ok?",
+		},
+		{
+			name:     "strips scripts and styles",
+			html:     "<html><head><title>T</title><style>p{color:red}</style></head><body><script>alert(1)</script><p>Visible</p></body></html>",
+			expected: "Visible",
+		},
+		{
+			name:     "drops hidden preheader",
+			html:     `<div style="display: none; max-height:0">preheader</div><p>Body</p><span hidden>x</span>`,
+			expected: "Body",
+		},
+		{
+			name:     "unordered list",
+			html:     "<p>Items:</p><ul><li>One</li><li>Two</li></ul><p>After</p>",
+			expected: "Items:\n\n- One\n- Two\n\nAfter",
+		},
+		{
+			name:     "ordered nested list",
+			html:     `<ol start="3"><li>Three<ul><li>sub</li></ul></li><li>Four</li></ol>`,
+			expected: "3. Three\n   - sub\n4. Four",
+		},
+		{
+			name:     "table",
+			html:     "<table><tr><th>Name</th><th>Qty</th></tr><tr><td>Apple</td><td>2</td></tr></table>",
+			expected: "Name | Qty\nApple | 2",
+		},
+		{
+			name:     "layout table cells with blocks",
+			html:     "<table><tr><td><p>Left</p></td><td><p>Right</p></td></tr></table>",
+			expected: "Left\n\nRight",
+		},
+		{
+			name:     "link footnotes",
+			html:     `<p>See <a href="https://example.com/a">the docs</a> and <a href="https://example.com/a">again</a> or <a href="https://b.example">b</a>.</p>`,
+			expected: "See the docs [1] and again [1] or b [2].\n\n[1] https://example.com/a\n[2] https://b.example",
+		},
+		{
+			name:     "link text equal to url",
+			html:     `<a href="https://example.com/">https://example.com</a> <a href="mailto:a@example.com">a@example.com</a> <a href="#top">top</a>`,
+			expected: "https://example.com a@example.com top",
+		},
+		{
+			name:     "blockquote",
+			html:     "<p>Reply</p><blockquote><p>Quoted one</p><p>Quoted two</p></blockquote>",
+			expected: "Reply\n\n> Quoted one\n>\n> Quoted two",
+		},
+		{
+			name:     "preformatted",
+			html:     "<pre>a  b\n  c</pre>",
+			expected: "a  b\n  c",
+		},
+		{
+			name:     "headings and rule",
+			html:     "<h1>Title</h1>Text<hr>More",
+			expected: "Title\n\nText\n\n---\n\nMore",
+		},
+		{
+			name:     "entities and nbsp",
+			html:     "Fish&nbsp;&amp;&nbsp;Chips &lt;3",
+			expected: "Fish & Chips <3",
+		},
+		{
+			name:     "image alt text",
+			html:     `<p>Logo: <img src="cid:logo" alt="ACME"> <img src="x.png"></p>`,
+			expected: "Logo: [ACME]",
+		},
+		{
+			name:     "empty",
+			html:     "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToText(tt.html))
+		})
+	}
+}
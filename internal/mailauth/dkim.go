@@ -0,0 +1,478 @@
+package mailauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DKIM verification results, using Authentication-Results terminology
+const (
+	ResultPass      = "pass"
+	ResultFail      = "fail"
+	ResultPermError = "permerror"
+	ResultTempError = "temperror"
+)
+
+// DKIMVerification is the outcome of verifying one DKIM-Signature locally
+type DKIMVerification struct {
+	Domain    string `json:"domain"`
+	Selector  string `json:"selector"`
+	Algorithm string `json:"algorithm"`
+	Result    string `json:"result"`
+	Reason    string `json:"reason,omitempty"`
+	BodyHash  string `json:"bodyHash,omitempty"`
+}
+
+// headerField is a raw header field as it appears in the message
+type headerField struct {
+	name string
+	raw  string // "Name: value\r\n" including any folding
+}
+
+// signatureBValue matches the b= tag of a DKIM-Signature (but not bh=)
+var signatureBValue = regexp.MustCompile(`(^|;)(\s*b\s*=)[^;]*`)
+
+// VerifyDKIM verifies every DKIM-Signature in a raw RFC 5322 message
+// (RFC 6376, with Ed25519 per RFC 8463). Public keys are fetched through
+// resolver, so callers can supply keys from DNS or from a local file.
+// Signatures whose x= expiry is before now fail.
+func VerifyDKIM(raw []byte, resolver KeyResolver, now time.Time) ([]DKIMVerification, error) {
+	headers, body, err := splitMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []DKIMVerification
+	for _, h := range headers {
+		if !strings.EqualFold(h.name, "DKIM-Signature") {
+			continue
+		}
+		results = append(results, verifySignature(h, headers, body, resolver, now))
+	}
+
+	return results, nil
+}
+
+func verifySignature(sigField headerField, headers []headerField, body []byte, resolver KeyResolver, now time.Time) DKIMVerification {
+	v := DKIMVerification{Result: ResultPermError}
+
+	_, value, _ := strings.Cut(sigField.raw, ":")
+	tags, err := ParseTagList(value)
+	if err != nil {
+		v.Reason = "malformed signature: " + err.Error()
+		return v
+	}
+
+	v.Domain = strings.ToLower(tags["d"])
+	v.Selector = tags["s"]
+	v.Algorithm = strings.ToLower(tags["a"])
+
+	for _, required := range []string{"v", "a", "b", "bh", "d", "h", "s"} {
+		if _, ok := tags[required]; !ok {
+			v.Reason = fmt.Sprintf("missing required tag %s=", required)
+			return v
+		}
+	}
+	if tags["v"] != "1" {
+		v.Reason = "unsupported version " + tags["v"]
+		return v
+	}
+
+	// The agent identifier must be the signing domain or a subdomain of it
+	// (RFC 6376 section 3.5)
+	identity := "@" + v.Domain
+	if i, ok := tags["i"]; ok {
+		identity = strings.ToLower(i)
+	}
+	identityDomain := identity[strings.LastIndex(identity, "@")+1:]
+	if identityDomain != v.Domain && !strings.HasSuffix(identityDomain, "."+v.Domain) {
+		v.Reason = "i= domain " + identityDomain + " is not within d=" + v.Domain
+		return v
+	}
+
+	signedHeaders := splitHeaderList(tags["h"])
+	if !containsFold(signedHeaders, "from") {
+		v.Reason = "From header is not signed"
+		return v
+	}
+
+	// RFC 8301 forbids treating rsa-sha1 signatures as valid
+	var keyType string
+	switch v.Algorithm {
+	case "rsa-sha256":
+		keyType = "rsa"
+	case "ed25519-sha256":
+		keyType = "ed25519"
+	case "rsa-sha1":
+		v.Reason = "rsa-sha1 is not accepted (RFC 8301)"
+		return v
+	default:
+		v.Reason = "unsupported algorithm " + v.Algorithm
+		return v
+	}
+
+	if x, ok := tags["x"]; ok {
+		expires, err := strconv.ParseInt(x, 10, 64)
+		if err != nil {
+			v.Reason = "invalid x= tag"
+			return v
+		}
+		if t, err := strconv.ParseInt(tags["t"], 10, 64); err == nil && expires < t {
+			v.Reason = "x= is before t="
+			return v
+		}
+		if now.Unix() > expires {
+			v.Result = ResultFail
+			v.Reason = "signature expired " + time.Unix(expires, 0).UTC().Format(time.RFC3339)
+			return v
+		}
+	}
+
+	headerCanon, bodyCanon, err := parseCanonicalization(tags["c"])
+	if err != nil {
+		v.Reason = err.Error()
+		return v
+	}
+
+	// Body hash
+	canonBody := canonicalizeBody(body, bodyCanon)
+	if l, ok := tags["l"]; ok {
+		n, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || n < 0 {
+			v.Reason = "invalid l= tag"
+			return v
+		}
+		if n > int64(len(canonBody)) {
+			v.Result = ResultFail
+			v.Reason = "l= exceeds body length"
+			return v
+		}
+		canonBody = canonBody[:n]
+	}
+	bh := sha256.New()
+	bh.Write(canonBody)
+	v.BodyHash = base64.StdEncoding.EncodeToString(bh.Sum(nil))
+	if v.BodyHash != tags["bh"] {
+		v.Result = ResultFail
+		v.Reason = "body hash did not verify"
+		return v
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		v.Reason = "invalid signature encoding"
+		return v
+	}
+
+	digest := headerHash(headers, signedHeaders, sigField, headerCanon, sha256.New)
+
+	key, err := lookupKey(resolver, v.Selector, v.Domain)
+	if err != nil {
+		var tempErr *tempKeyError
+		if errors.As(err, &tempErr) {
+			v.Result = ResultTempError
+		}
+		v.Reason = err.Error()
+		return v
+	}
+
+	if key.strict && identityDomain != v.Domain {
+		v.Reason = "key requires i= to match d= exactly (t=s)"
+		return v
+	}
+	if key.hashes != nil && !containsFold(key.hashes, "sha256") {
+		v.Reason = "key does not allow sha256 (h=" + strings.Join(key.hashes, ":") + ")"
+		return v
+	}
+
+	switch pub := key.pub.(type) {
+	case *rsa.PublicKey:
+		if keyType != "rsa" {
+			v.Reason = "key type does not match algorithm"
+			return v
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, signature); err != nil {
+			v.Result = ResultFail
+			v.Reason = "signature did not verify"
+			return v
+		}
+	case ed25519.PublicKey:
+		if keyType != "ed25519" {
+			v.Reason = "key type does not match algorithm"
+			return v
+		}
+		// RFC 8463 signs the SHA-256 digest of the canonicalized headers
+		if !ed25519.Verify(pub, digest, signature) {
+			v.Result = ResultFail
+			v.Reason = "signature did not verify"
+			return v
+		}
+	default:
+		v.Reason = "unsupported key type"
+		return v
+	}
+
+	v.Result = ResultPass
+	v.Reason = ""
+	return v
+}
+
+// headerHash computes the hash of the signed headers followed by the
+// DKIM-Signature itself with an empty b= value (RFC 6376 section 3.7)
+func headerHash(headers []headerField, signedHeaders []string, sigField headerField, canon string, newHash func() hash.Hash) []byte {
+	h := newHash()
+	for _, field := range selectSignedHeaders(headers, signedHeaders, sigField) {
+		h.Write([]byte(canonicalizeHeader(field, canon)))
+	}
+
+	_, value, _ := strings.Cut(sigField.raw, ":")
+	unsigned := headerField{
+		name: sigField.name,
+		raw:  sigField.name + ":" + signatureBValue.ReplaceAllString(value, "$1$2"),
+	}
+	h.Write([]byte(strings.TrimSuffix(canonicalizeHeader(unsigned, canon), "\r\n")))
+
+	return h.Sum(nil)
+}
+
+// splitMessage separates a raw message into header fields and body,
+// normalizing bare LF line endings to CRLF
+func splitMessage(raw []byte) ([]headerField, []byte, error) {
+	msg := normalizeCRLF(raw)
+
+	var headerBlock, body []byte
+	if idx := bytes.Index(msg, []byte("\r\n\r\n")); idx >= 0 {
+		headerBlock, body = msg[:idx+2], msg[idx+4:]
+	} else {
+		headerBlock = msg
+	}
+
+	var fields []headerField
+	for _, line := range strings.SplitAfter(string(headerBlock), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].raw += line
+			continue
+		}
+		name, _, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("malformed header line: %q", strings.TrimSpace(line))
+		}
+		fields = append(fields, headerField{name: strings.TrimSpace(name), raw: line})
+	}
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("message has no headers")
+	}
+
+	return fields, body, nil
+}
+
+// selectSignedHeaders picks the header instances named in h=, using the last
+// not-yet-used instance of each name as RFC 6376 section 5.4.2 requires.
+// The signature being verified is never selected.
+func selectSignedHeaders(headers []headerField, names []string, sig headerField) []headerField {
+	used := make(map[int]bool)
+	var selected []headerField
+	for _, name := range names {
+		for i := len(headers) - 1; i >= 0; i-- {
+			if used[i] || headers[i] == sig || !strings.EqualFold(headers[i].name, name) {
+				continue
+			}
+			used[i] = true
+			selected = append(selected, headers[i])
+			break
+		}
+	}
+	return selected
+}
+
+func parseCanonicalization(c string) (string, string, error) {
+	if c == "" {
+		return "simple", "simple", nil
+	}
+	header, body, ok := strings.Cut(strings.ToLower(c), "/")
+	if !ok {
+		body = "simple"
+	}
+	for _, alg := range []string{header, body} {
+		if alg != "simple" && alg != "relaxed" {
+			return "", "", fmt.Errorf("unsupported canonicalization %q", c)
+		}
+	}
+	return header, body, nil
+}
+
+// canonicalizeHeader applies the simple or relaxed header canonicalization
+func canonicalizeHeader(field headerField, alg string) string {
+	if alg == "simple" {
+		return field.raw
+	}
+
+	name, value, _ := strings.Cut(field.raw, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// canonicalizeBody applies the simple or relaxed body canonicalization
+func canonicalizeBody(body []byte, alg string) []byte {
+	if alg == "relaxed" {
+		lines := strings.Split(string(body), "\r\n")
+		for i, line := range lines {
+			line = strings.TrimRightFunc(line, isWSP)
+			lines[i] = collapseWSP(line)
+		}
+		body = []byte(strings.Join(lines, "\r\n"))
+	}
+
+	// Remove all trailing empty lines, then terminate the last line
+	trimmed := bytes.TrimRight(body, "\r\n")
+	if len(trimmed) == 0 {
+		if alg == "relaxed" {
+			return []byte{}
+		}
+		return []byte("\r\n")
+	}
+	return append(trimmed, '\r', '\n')
+}
+
+func collapseWSP(s string) string {
+	var b strings.Builder
+	inWSP := false
+	for _, r := range s {
+		if isWSP(r) {
+			if !inWSP {
+				b.WriteByte(' ')
+			}
+			inWSP = true
+			continue
+		}
+		inWSP = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func normalizeCRLF(raw []byte) []byte {
+	var b bytes.Buffer
+	b.Grow(len(raw))
+	for i, c := range raw {
+		if c == '\n' && (i == 0 || raw[i-1] != '\r') {
+			b.WriteByte('\r')
+		}
+		b.WriteByte(c)
+	}
+	return b.Bytes()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// tempKeyError marks key lookup failures that may succeed on retry
+type tempKeyError struct {
+	err error
+}
+
+func (e *tempKeyError) Error() string { return "key lookup failed: " + e.err.Error() }
+func (e *tempKeyError) Unwrap() error { return e.err }
+
+// dkimKey is a parsed DKIM key record
+type dkimKey struct {
+	pub crypto.PublicKey
+	// hashes lists the hash algorithms the key allows (h=); nil allows any
+	hashes []string
+	// strict is set by the t=s flag, which forbids subdomains in i=
+	strict bool
+}
+
+// lookupKey fetches and parses the public key record for selector and domain
+func lookupKey(resolver KeyResolver, selector, domain string) (*dkimKey, error) {
+	name := selector + "._domainkey." + domain
+	records, err := resolver.LookupTXT(name)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, fmt.Errorf("no key record at %s", name)
+		}
+		return nil, &tempKeyError{err: err}
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no key record at %s", name)
+	}
+
+	tags, err := ParseTagList(strings.Join(records, ""))
+	if err != nil {
+		return nil, fmt.Errorf("malformed key record at %s: %w", name, err)
+	}
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		return nil, fmt.Errorf("unsupported key record version %q", v)
+	}
+
+	p := tags["p"]
+	if p == "" {
+		return nil, fmt.Errorf("key at %s has been revoked", name)
+	}
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding at %s", name)
+	}
+
+	key := &dkimKey{}
+	if h, ok := tags["h"]; ok {
+		key.hashes = splitHeaderList(strings.ToLower(h))
+	}
+	for _, flag := range splitHeaderList(strings.ToLower(tags["t"])) {
+		if flag == "s" {
+			key.strict = true
+		}
+	}
+
+	switch strings.ToLower(tags["k"]) {
+	case "", "rsa":
+		if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+			rsaKey, ok := pub.(*rsa.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("key at %s is not an RSA key", name)
+			}
+			key.pub = rsaKey
+			return key, nil
+		}
+		pub, err := x509.ParsePKCS1PublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA key at %s", name)
+		}
+		key.pub = pub
+		return key, nil
+	case "ed25519":
+		if len(der) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key at %s", name)
+		}
+		key.pub = ed25519.PublicKey(der)
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q at %s", tags["k"], name)
+	}
+}
@@ -0,0 +1,417 @@
+package mailauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHeaders = "From: Alice <alice@example.com>\r\n" +
+	"To: bob@example.net\r\n" +
+	"Subject: Quarterly  report\r\n" +
+	"Date: Mon, 1 Jan 2024 12:00:00 +0000\r\n"
+
+const testBody = "Hello Bob,\r\n\r\nThe report is attached.  \r\n\r\n\r\n"
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// rfc8463Message is the signed example message of RFC 8463 Appendix A.3,
+// carrying an Ed25519 and an RSA signature made by an independent signer
+const rfc8463Message = `DKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed;
+ d=football.example.com; i=@football.example.com;
+ q=dns/txt; s=brisbane; t=1528637909; h=from : to :
+ subject : date : message-id : from : subject : date;
+ bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;
+ b=/gCrinpcQOoIfuHNQIbq4pgh9kyIK3AQUdt9OdqQehSwhEIug4D11Bus
+ Fa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==
+DKIM-Signature: v=1; a=rsa-sha256; c=relaxed/relaxed;
+ d=football.example.com; i=@football.example.com;
+ q=dns/txt; s=test; t=1528637909; h=from : to : subject :
+ date : message-id : from : subject : date;
+ bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;
+ b=F45dVWDfMbQDGHJFlXUNB2HKfbCeLRyhDXgFpEL8GwpsRe0IeIixNTe3
+ DhCVlUrSjV4BwcVcOF6+FF3Zo9Rpo1tFOeS9mPYQTnGdaSGsgeefOsk2Jz
+ dA+L10TeYt9BgDfQNZtKdN1WO//KgIqXP7OdEFE4LjFYNcUxZQ4FADY+8=
+From: Joe SixPack <joe@football.example.com>
+To: Suzie Q <suzie@shopping.example.net>
+Subject: Is dinner ready?
+Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)
+Message-ID: <20030712040037.46341.5F8J@football.example.com>
+
+Hi.
+
+We lost the game.  Are you hungry yet?
+
+Joe.
+`
+
+// rfc8463Keys are the key records of RFC 8463 Appendix A.2
+var rfc8463Keys = StaticResolver{
+	"brisbane._domainkey.football.example.com": "v=DKIM1; k=ed25519; p=11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=",
+	"test._domainkey.football.example.com":     "v=DKIM1; k=rsa; p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDkHlOQoBTzWRiGs5V6NpP3idY6Wk08a5qhdR6wy5bdOKb2jLQiY/J16JYi0Qvx/byYzCNb3W91y3FutACDfzwQ/BC/e/8uBsCR+yz1Lxj+PL6lHvqMKrM3rG4hstT5QjvHO9PzoxZyVYLzBfO2EeC3Ip3G+2kryOTIKT+l/K4w3QIDAQAB",
+}
+
+// signTestMessage signs a message using the package's canonicalization so the
+// verifier can be exercised end to end
+func signTestMessage(t *testing.T, algorithm, canon string, sign func(digest []byte) []byte) string {
+	t.Helper()
+
+	headerCanon, bodyCanon, err := parseCanonicalization(canon)
+	require.NoError(t, err)
+
+	bh := sha256.Sum256(canonicalizeBody([]byte(testBody), bodyCanon))
+	sigValue := fmt.Sprintf(" v=1; a=%s; c=%s; d=example.com; s=sel;\r\n\th=From:To:Subject; bh=%s;\r\n\tb=",
+		algorithm, canon, base64.StdEncoding.EncodeToString(bh[:]))
+
+	unsigned := "DKIM-Signature:" + sigValue + "\r\n" + testHeaders + "\r\n" + testBody
+	headers, _, err := splitMessage([]byte(unsigned))
+	require.NoError(t, err)
+
+	digest := headerHash(headers, []string{"From", "To", "Subject"}, headers[0], headerCanon, sha256.New)
+	b := base64.StdEncoding.EncodeToString(sign(digest))
+
+	return "DKIM-Signature:" + sigValue + b + "\r\n" + testHeaders + "\r\n" + testBody
+}
+
+func rsaFixture(t *testing.T) (*rsa.PrivateKey, StaticResolver) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, StaticResolver{
+		"sel._domainkey.example.com": "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der),
+	}
+}
+
+func TestVerifyDKIMRSA(t *testing.T) {
+	key, resolver := rsaFixture(t)
+	signRSA := func(digest []byte) []byte {
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+		require.NoError(t, err)
+		return sig
+	}
+
+	for _, canon := range []string{"simple/simple", "relaxed/relaxed", "relaxed/simple", "simple/relaxed"} {
+		t.Run("passes with "+canon, func(t *testing.T) {
+			msg := signTestMessage(t, "rsa-sha256", canon, signRSA)
+
+			results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+			assert.Equal(t, "example.com", results[0].Domain)
+			assert.Equal(t, "sel", results[0].Selector)
+		})
+	}
+
+	t.Run("relaxed tolerates header whitespace changes", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "relaxed/relaxed", signRSA)
+		msg = strings.Replace(msg, "Subject: Quarterly  report", "subject:   Quarterly report ", 1)
+
+		results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+	})
+
+	t.Run("accepts bare LF line endings", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "relaxed/relaxed", signRSA)
+
+		results, err := VerifyDKIM([]byte(strings.ReplaceAll(msg, "\r\n", "\n")), resolver, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+	})
+
+	t.Run("fails when body is modified", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "simple/simple", signRSA)
+		msg = strings.Replace(msg, "The report", "The invoice", 1)
+
+		results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultFail, results[0].Result)
+		assert.Contains(t, results[0].Reason, "body hash")
+	})
+
+	t.Run("fails when a signed header is modified", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "relaxed/relaxed", signRSA)
+		msg = strings.Replace(msg, "Subject: Quarterly  report", "Subject: Urgent wire transfer", 1)
+
+		results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultFail, results[0].Result)
+		assert.Contains(t, results[0].Reason, "signature")
+	})
+
+	t.Run("ignores unsigned headers", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "simple/simple", signRSA)
+		msg = "X-Added-Later: yes\r\n" + msg
+
+		results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+	})
+
+	t.Run("reports permerror when key is missing", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "simple/simple", signRSA)
+
+		results, err := VerifyDKIM([]byte(msg), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+	})
+
+	t.Run("reports temperror when lookup fails", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "simple/simple", signRSA)
+
+		results, err := VerifyDKIM([]byte(msg), failingResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultTempError, results[0].Result)
+	})
+
+	t.Run("reports permerror for revoked key", func(t *testing.T) {
+		msg := signTestMessage(t, "rsa-sha256", "simple/simple", signRSA)
+
+		results, err := VerifyDKIM([]byte(msg), StaticResolver{"sel._domainkey.example.com": "v=DKIM1; p="}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+		assert.Contains(t, results[0].Reason, "revoked")
+	})
+}
+
+func TestVerifyDKIMEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	resolver := StaticResolver{
+		"sel._domainkey.example.com": "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub),
+	}
+
+	msg := signTestMessage(t, "ed25519-sha256", "relaxed/relaxed", func(digest []byte) []byte {
+		return ed25519.Sign(priv, digest)
+	})
+
+	results, err := VerifyDKIM([]byte(msg), resolver, testNow)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+	assert.Equal(t, "ed25519-sha256", results[0].Algorithm)
+
+	tampered := strings.Replace(msg, "To: bob@example.net", "To: mallory@example.net", 1)
+	results, err = VerifyDKIM([]byte(tampered), resolver, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, ResultFail, results[0].Result)
+}
+
+func TestVerifyDKIMRFC8463(t *testing.T) {
+	results, err := VerifyDKIM([]byte(rfc8463Message), rfc8463Keys, testNow)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "ed25519-sha256", results[0].Algorithm)
+	assert.Equal(t, ResultPass, results[0].Result, results[0].Reason)
+	assert.Equal(t, "rsa-sha256", results[1].Algorithm)
+	assert.Equal(t, ResultPass, results[1].Result, results[1].Reason)
+
+	tampered := strings.Replace(rfc8463Message, "We lost the game.", "We won the game.", 1)
+	results, err = VerifyDKIM([]byte(tampered), rfc8463Keys, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, ResultFail, results[0].Result)
+	assert.Equal(t, ResultFail, results[1].Result)
+}
+
+func TestVerifyDKIMIdentityAndKeyTags(t *testing.T) {
+	const (
+		ed25519Key = "v=DKIM1; k=ed25519; p=11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="
+		signer     = "i=@football.example.com;"
+	)
+
+	tests := []struct {
+		name     string
+		identity string
+		keyTags  string
+		expected string
+		reason   string
+	}{
+		{"i= equal to d=", signer, "", ResultPass, ""},
+		{"i= outside d=", "i=@example.org;", "", ResultPermError, "not within d="},
+		{"i= on a lookalike domain", "i=@evilfootball.example.com;", "", ResultPermError, "not within d="},
+		{"i= subdomain is allowed", "i=joe@news.football.example.com;", "", ResultFail, "signature did not verify"},
+		{"t=s accepts i= equal to d=", signer, "t=s; ", ResultPass, ""},
+		{"t=s rejects i= subdomain", "i=joe@news.football.example.com;", "t=y:s; ", ResultPermError, "t=s"},
+		{"h= listing sha256", signer, "h=sha1:sha256; ", ResultPass, ""},
+		{"h= without sha256", signer, "h=sha1; ", ResultPermError, "does not allow sha256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Only the Ed25519 signature of the RFC 8463 message is checked
+			msg := strings.Replace(rfc8463Message, signer, tt.identity, 1)
+			keys := StaticResolver{
+				"brisbane._domainkey.football.example.com": strings.Replace(ed25519Key, "k=", tt.keyTags+"k=", 1),
+			}
+
+			results, err := VerifyDKIM([]byte(msg), keys, testNow)
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, tt.expected, results[0].Result, results[0].Reason)
+			assert.Contains(t, results[0].Reason, tt.reason)
+		})
+	}
+}
+
+func TestVerifyDKIMPolicy(t *testing.T) {
+	signature := func(tags string) []byte {
+		return []byte("DKIM-Signature: v=1; d=example.com; s=sel; h=From; bh=x; b=y; " + tags + "\r\n" + testHeaders + "\r\n" + testBody)
+	}
+
+	t.Run("rejects rsa-sha1", func(t *testing.T) {
+		results, err := VerifyDKIM(signature("a=rsa-sha1"), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+		assert.Contains(t, results[0].Reason, "RFC 8301")
+	})
+
+	t.Run("fails expired signatures", func(t *testing.T) {
+		expired := testNow.Add(-time.Hour).Unix()
+		results, err := VerifyDKIM(signature(fmt.Sprintf("a=rsa-sha256; x=%d", expired)), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultFail, results[0].Result)
+		assert.Contains(t, results[0].Reason, "expired")
+	})
+
+	t.Run("checks unexpired signatures", func(t *testing.T) {
+		expires := testNow.Add(time.Hour).Unix()
+		results, err := VerifyDKIM(signature(fmt.Sprintf("a=rsa-sha256; x=%d", expires)), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultFail, results[0].Result)
+		assert.Contains(t, results[0].Reason, "body hash")
+	})
+
+	t.Run("rejects x= before t=", func(t *testing.T) {
+		results, err := VerifyDKIM(signature("a=rsa-sha256; t=1700000000; x=1600000000"), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+	})
+}
+
+func TestVerifyDKIMMalformed(t *testing.T) {
+	t.Run("no signatures", func(t *testing.T) {
+		results, err := VerifyDKIM([]byte(testHeaders+"\r\n"+testBody), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("missing required tags", func(t *testing.T) {
+		msg := "DKIM-Signature: v=1; a=rsa-sha256; d=example.com\r\n" + testHeaders + "\r\n" + testBody
+		results, err := VerifyDKIM([]byte(msg), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+	})
+
+	t.Run("From not signed", func(t *testing.T) {
+		msg := "DKIM-Signature: v=1; a=rsa-sha256; d=example.com; s=sel; h=To; bh=x; b=y\r\n" + testHeaders + "\r\n" + testBody
+		results, err := VerifyDKIM([]byte(msg), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+		assert.Contains(t, results[0].Reason, "From")
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		msg := "DKIM-Signature: v=1; a=dsa-sha512; d=example.com; s=sel; h=From; bh=x; b=y\r\n" + testHeaders + "\r\n" + testBody
+		results, err := VerifyDKIM([]byte(msg), StaticResolver{}, testNow)
+		require.NoError(t, err)
+		assert.Equal(t, ResultPermError, results[0].Result)
+	})
+
+	t.Run("headers without colon", func(t *testing.T) {
+		_, err := VerifyDKIM([]byte("not a header\r\n\r\nbody"), StaticResolver{}, testNow)
+		assert.Error(t, err)
+	})
+}
+
+func TestCanonicalization(t *testing.T) {
+	// Example from RFC 6376 section 3.4.5
+	headers, body, err := splitMessage([]byte("A: X\r\nB : Y\t\r\n\tZ  \r\n\r\n C \r\nD \t E\r\n\r\n\r\n"))
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+
+	t.Run("relaxed headers", func(t *testing.T) {
+		assert.Equal(t, "a:X\r\n", canonicalizeHeader(headers[0], "relaxed"))
+		assert.Equal(t, "b:Y Z\r\n", canonicalizeHeader(headers[1], "relaxed"))
+	})
+
+	t.Run("simple headers", func(t *testing.T) {
+		assert.Equal(t, "A: X\r\n", canonicalizeHeader(headers[0], "simple"))
+		assert.Equal(t, "B : Y\t\r\n\tZ  \r\n", canonicalizeHeader(headers[1], "simple"))
+	})
+
+	t.Run("relaxed body", func(t *testing.T) {
+		assert.Equal(t, " C\r\nD E\r\n", string(canonicalizeBody(body, "relaxed")))
+	})
+
+	t.Run("simple body", func(t *testing.T) {
+		assert.Equal(t, " C \r\nD \t E\r\n", string(canonicalizeBody(body, "simple")))
+	})
+
+	t.Run("empty body hashes", func(t *testing.T) {
+		simple := sha256.Sum256(canonicalizeBody(nil, "simple"))
+		relaxed := sha256.Sum256(canonicalizeBody(nil, "relaxed"))
+		assert.Equal(t, "frcCV1k9oG9oKj3dpUqdJg1PxRT2RSN/XKdLCPjaYaY=", base64.StdEncoding.EncodeToString(simple[:]))
+		assert.Equal(t, "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", base64.StdEncoding.EncodeToString(relaxed[:]))
+	})
+}
+
+func TestSelectSignedHeaders(t *testing.T) {
+	headers := []headerField{
+		{name: "Received", raw: "Received: 2\r\n"},
+		{name: "Received", raw: "Received: 1\r\n"},
+		{name: "From", raw: "From: a\r\n"},
+	}
+
+	selected := selectSignedHeaders(headers, []string{"received", "Received", "Received", "from"}, headerField{})
+	assert.Equal(t, []headerField{headers[1], headers[0], headers[2]}, selected)
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	content := "# offline keys\n\n" +
+		"Sel._domainkey.Example.com. \"v=DKIM1; k=rsa; \" \"p=ABC\"\n" +
+		"k1._domainkey.example.net\tv=DKIM1; k=ed25519; p=XYZ\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	resolver, err := LoadKeyFile(path)
+	require.NoError(t, err)
+
+	records, err := resolver.LookupTXT("sel._domainkey.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v=DKIM1; k=rsa; p=ABC"}, records)
+
+	records, err = resolver.LookupTXT("k1._domainkey.example.net")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v=DKIM1; k=ed25519; p=XYZ"}, records)
+
+	_, err = resolver.LookupTXT("missing._domainkey.example.com")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, os.WriteFile(path, []byte("only-a-name\n"), 0600))
+	_, err = LoadKeyFile(path)
+	assert.Error(t, err)
+}
+
+type failingResolver struct{}
+
+func (failingResolver) LookupTXT(string) ([]string, error) {
+	return nil, errors.New("server misbehaving")
+}
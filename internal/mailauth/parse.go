@@ -0,0 +1,276 @@
+// Package mailauth parses and verifies email authentication headers:
+// Authentication-Results, Received-SPF, DKIM-Signature and the ARC set.
+package mailauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Header is a single message header
+type Header struct {
+	Name  string
+	Value string
+}
+
+// MethodResult is one method's result from an Authentication-Results header
+type MethodResult struct {
+	Method     string            `json:"method"`
+	Result     string            `json:"result"`
+	Reason     string            `json:"reason,omitempty"`
+	Comment    string            `json:"comment,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// AuthResults is a parsed Authentication-Results header (RFC 8601)
+type AuthResults struct {
+	AuthServID string         `json:"authServId"`
+	Results    []MethodResult `json:"results"`
+}
+
+// ReceivedSPF is a parsed Received-SPF header (RFC 7208 section 9.1)
+type ReceivedSPF struct {
+	Result  string            `json:"result"`
+	Comment string            `json:"comment,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// ParseAuthResults parses the value of an Authentication-Results header
+func ParseAuthResults(value string) (*AuthResults, error) {
+	segments := splitOutside(value, ';')
+	if len(segments) == 0 || strings.TrimSpace(stripComments(segments[0])) == "" {
+		return nil, fmt.Errorf("missing authserv-id")
+	}
+
+	idTokens := strings.Fields(stripComments(segments[0]))
+	ar := &AuthResults{AuthServID: idTokens[0], Results: []MethodResult{}}
+
+	for _, segment := range segments[1:] {
+		tokens, comments := tokenize(segment)
+		if len(tokens) == 0 {
+			continue
+		}
+		method, result, ok := strings.Cut(tokens[0], "=")
+		if !ok {
+			// "none" indicates no authentication was performed
+			continue
+		}
+		method, _, _ = strings.Cut(method, "/")
+
+		mr := MethodResult{
+			Method:  strings.ToLower(method),
+			Result:  strings.ToLower(result),
+			Comment: strings.Join(comments, " "),
+		}
+		for _, tok := range tokens[1:] {
+			key, val, ok := strings.Cut(tok, "=")
+			if !ok {
+				continue
+			}
+			val = unquote(val)
+			if strings.EqualFold(key, "reason") {
+				mr.Reason = val
+				continue
+			}
+			if mr.Properties == nil {
+				mr.Properties = make(map[string]string)
+			}
+			mr.Properties[strings.ToLower(key)] = val
+		}
+		ar.Results = append(ar.Results, mr)
+	}
+
+	return ar, nil
+}
+
+// ParseReceivedSPF parses the value of a Received-SPF header
+func ParseReceivedSPF(value string) (*ReceivedSPF, error) {
+	value = strings.TrimSpace(value)
+	end := strings.IndexAny(value, " \t(;")
+	if end < 0 {
+		end = len(value)
+	}
+	if end == 0 {
+		return nil, fmt.Errorf("missing SPF result")
+	}
+
+	spf := &ReceivedSPF{Result: strings.ToLower(value[:end])}
+	rest := value[end:]
+
+	_, comments := tokenize(rest)
+	spf.Comment = strings.Join(comments, " ")
+
+	for _, segment := range splitOutside(stripComments(rest), ';') {
+		key, val, ok := strings.Cut(strings.TrimSpace(segment), "=")
+		if !ok {
+			continue
+		}
+		if spf.Fields == nil {
+			spf.Fields = make(map[string]string)
+		}
+		spf.Fields[strings.ToLower(strings.TrimSpace(key))] = unquote(strings.TrimSpace(val))
+	}
+
+	return spf, nil
+}
+
+// ParseTagList parses a DKIM-style tag=value list (RFC 6376 section 3.2).
+// Whitespace is removed from values, which is correct for every tag gmro
+// inspects (including the base64 b= and bh= tags).
+func ParseTagList(value string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, segment := range strings.Split(value, ";") {
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(segment, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed tag %q", strings.TrimSpace(segment))
+		}
+		key = strings.TrimSpace(key)
+		if _, dup := tags[key]; dup {
+			return nil, fmt.Errorf("duplicate tag %q", key)
+		}
+		tags[key] = removeWhitespace(val)
+	}
+	return tags, nil
+}
+
+// splitOutside splits s on sep, ignoring separators inside quotes or comments
+func splitOutside(s string, sep rune) []string {
+	var parts []string
+	var current strings.Builder
+	depth, inQuote, escaped := 0, false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"' && depth == 0:
+			inQuote = !inQuote
+		case r == '(' && !inQuote:
+			depth++
+		case r == ')' && !inQuote && depth > 0:
+			depth--
+		case r == sep && !inQuote && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(parts, current.String())
+}
+
+// tokenize splits a segment into whitespace-separated tokens, collecting the
+// text of parenthesized comments separately. Quoted strings stay in one token.
+func tokenize(s string) ([]string, []string) {
+	var tokens, comments []string
+	var token, comment strings.Builder
+	depth, inQuote, escaped := 0, false, false
+
+	flush := func() {
+		if token.Len() > 0 {
+			tokens = append(tokens, token.String())
+			token.Reset()
+		}
+	}
+
+	for _, r := range s {
+		if depth > 0 {
+			switch {
+			case escaped:
+				escaped = false
+				comment.WriteRune(r)
+			case r == '\\':
+				escaped = true
+			case r == '(':
+				depth++
+				comment.WriteRune(r)
+			case r == ')':
+				depth--
+				if depth == 0 {
+					comments = append(comments, strings.TrimSpace(comment.String()))
+					comment.Reset()
+				} else {
+					comment.WriteRune(r)
+				}
+			default:
+				comment.WriteRune(r)
+			}
+			continue
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+			token.WriteRune(r)
+		case r == '\\' && inQuote:
+			escaped = true
+			token.WriteRune(r)
+		case r == '"':
+			inQuote = !inQuote
+			token.WriteRune(r)
+		case r == '(' && !inQuote:
+			flush()
+			depth++
+		case (r == ' ' || r == '\t' || r == '\r' || r == '\n') && !inQuote:
+			flush()
+		default:
+			token.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens, comments
+}
+
+// stripComments removes parenthesized comments outside quoted strings
+func stripComments(s string) string {
+	var b strings.Builder
+	depth, inQuote, escaped := 0, false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			if depth == 0 {
+				b.WriteRune(r)
+			}
+			continue
+		case r == '\\':
+			escaped = true
+		case r == '"' && depth == 0:
+			inQuote = !inQuote
+		case r == '(' && !inQuote:
+			depth++
+			continue
+		case r == ')' && !inQuote && depth > 0:
+			depth--
+			continue
+		}
+		if depth == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+		return strings.ReplaceAll(s, `\"`, `"`)
+	}
+	return s
+}
+
+func removeWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, s)
+}
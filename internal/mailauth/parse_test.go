@@ -0,0 +1,90 @@
+package mailauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthResults(t *testing.T) {
+	t.Run("parses Gmail style header", func(t *testing.T) {
+		value := `mx.google.com;
+       dkim=pass header.i=@example.com header.s=s1 header.b=AbCd1234;
+       spf=pass (google.com: domain of bounce@example.com designates 192.0.2.1 as permitted sender) smtp.mailfrom=bounce@example.com;
+       dmarc=pass (p=REJECT sp=REJECT dis=NONE) header.from=example.com`
+
+		ar, err := ParseAuthResults(value)
+		require.NoError(t, err)
+
+		assert.Equal(t, "mx.google.com", ar.AuthServID)
+		require.Len(t, ar.Results, 3)
+
+		assert.Equal(t, "dkim", ar.Results[0].Method)
+		assert.Equal(t, "pass", ar.Results[0].Result)
+		assert.Equal(t, "@example.com", ar.Results[0].Properties["header.i"])
+		assert.Equal(t, "s1", ar.Results[0].Properties["header.s"])
+
+		assert.Equal(t, "spf", ar.Results[1].Method)
+		assert.Equal(t, "bounce@example.com", ar.Results[1].Properties["smtp.mailfrom"])
+		assert.Contains(t, ar.Results[1].Comment, "designates 192.0.2.1")
+
+		assert.Equal(t, "dmarc", ar.Results[2].Method)
+		assert.Equal(t, "p=REJECT sp=REJECT dis=NONE", ar.Results[2].Comment)
+		assert.Equal(t, "example.com", ar.Results[2].Properties["header.from"])
+	})
+
+	t.Run("handles version, reason and quoted values", func(t *testing.T) {
+		ar, err := ParseAuthResults(`example.org 1; dkim/1=FAIL reason="signature; verification failed" header.d=example.com`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "example.org", ar.AuthServID)
+		require.Len(t, ar.Results, 1)
+		assert.Equal(t, "dkim", ar.Results[0].Method)
+		assert.Equal(t, "fail", ar.Results[0].Result)
+		assert.Equal(t, "signature; verification failed", ar.Results[0].Reason)
+		assert.Equal(t, "example.com", ar.Results[0].Properties["header.d"])
+	})
+
+	t.Run("handles no results", func(t *testing.T) {
+		ar, err := ParseAuthResults("example.org; none")
+		require.NoError(t, err)
+		assert.Empty(t, ar.Results)
+	})
+
+	t.Run("rejects missing authserv-id", func(t *testing.T) {
+		_, err := ParseAuthResults("  ; spf=pass")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseReceivedSPF(t *testing.T) {
+	spf, err := ParseReceivedSPF(`softfail (google.com: domain of transitioning a@example.com does not designate 192.0.2.1 as permitted sender) client-ip=192.0.2.1; envelope-from="a@example.com"; helo=mail.example.com;`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "softfail", spf.Result)
+	assert.Contains(t, spf.Comment, "does not designate")
+	assert.Equal(t, "192.0.2.1", spf.Fields["client-ip"])
+	assert.Equal(t, "a@example.com", spf.Fields["envelope-from"])
+	assert.Equal(t, "mail.example.com", spf.Fields["helo"])
+
+	_, err = ParseReceivedSPF("")
+	assert.Error(t, err)
+}
+
+func TestParseTagList(t *testing.T) {
+	tags, err := ParseTagList("v=1; a=rsa-sha256; d=example.com; s=sel;\r\n h=From : To; bh=abc\r\n def=; b=")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", tags["v"])
+	assert.Equal(t, "rsa-sha256", tags["a"])
+	assert.Equal(t, "From:To", tags["h"])
+	assert.Equal(t, "abcdef=", tags["bh"])
+	assert.Equal(t, "", tags["b"])
+
+	_, err = ParseTagList("v=1; d=a; d=b")
+	assert.Error(t, err)
+
+	_, err = ParseTagList("v=1; garbage")
+	assert.Error(t, err)
+}
@@ -0,0 +1,292 @@
+package mailauth
+
+import (
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Verdict summarizes one authentication mechanism
+type Verdict struct {
+	Result   string `json:"result"`
+	Domain   string `json:"domain,omitempty"`
+	Selector string `json:"selector,omitempty"`
+	Aligned  bool   `json:"aligned"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// DKIMSignature summarizes a DKIM-Signature header
+type DKIMSignature struct {
+	Domain    string   `json:"domain"`
+	Selector  string   `json:"selector"`
+	Algorithm string   `json:"algorithm"`
+	Headers   []string `json:"headers,omitempty"`
+	Aligned   bool     `json:"aligned"`
+}
+
+// ARCSet summarizes one ARC instance (RFC 8617)
+type ARCSet struct {
+	Instance        int          `json:"instance"`
+	ChainValidation string       `json:"chainValidation,omitempty"`
+	SealDomain      string       `json:"sealDomain,omitempty"`
+	SignatureDomain string       `json:"signatureDomain,omitempty"`
+	AuthResults     *AuthResults `json:"authResults,omitempty"`
+}
+
+// DefaultAuthServID is the authserv-id Gmail puts in the
+// Authentication-Results headers it adds on receipt
+const DefaultAuthServID = "mx.google.com"
+
+// Report is the authentication verdict for a message
+type Report struct {
+	From       string `json:"from"`
+	FromDomain string `json:"fromDomain"`
+	// AuthServID is the authserv-id of the Authentication-Results header the
+	// verdict is based on. It is empty when no header from the trusted
+	// receiver was found, in which case the verdict is unverified.
+	AuthServID            string          `json:"authServId,omitempty"`
+	Verified              bool            `json:"verified"`
+	SPF                   Verdict         `json:"spf"`
+	DKIM                  []Verdict       `json:"dkim"`
+	DMARC                 Verdict         `json:"dmarc"`
+	ARC                   Verdict         `json:"arc"`
+	AuthenticationResults []*AuthResults  `json:"authenticationResults,omitempty"`
+	ReceivedSPF           []*ReceivedSPF  `json:"receivedSpf,omitempty"`
+	DKIMSignatures        []DKIMSignature `json:"dkimSignatures,omitempty"`
+	ARCSets               []ARCSet        `json:"arcSets,omitempty"`
+
+	// LocalDKIM holds the results of verifying signatures locally with
+	// VerifyDKIM; it is left empty by Analyze
+	LocalDKIM []DKIMVerification `json:"localDkim,omitempty"`
+}
+
+// Analyze builds an authentication report from a message's headers.
+//
+// Headers are expected in their original order (newest first). The verdict is
+// based on the topmost Authentication-Results header whose authserv-id is
+// authServID, the receiving service; any sender can add headers of their
+// own, so the others are only reported for reference.
+func Analyze(headers []Header, authServID string) *Report {
+	r := &Report{DKIM: []Verdict{}}
+
+	for _, h := range headers {
+		name := strings.ToLower(h.Name)
+		switch name {
+		case "from":
+			if r.From == "" {
+				r.From = h.Value
+				r.FromDomain = addressDomain(h.Value)
+			}
+		case "authentication-results":
+			if ar, err := ParseAuthResults(h.Value); err == nil {
+				r.AuthenticationResults = append(r.AuthenticationResults, ar)
+			}
+		case "received-spf":
+			if spf, err := ParseReceivedSPF(h.Value); err == nil {
+				r.ReceivedSPF = append(r.ReceivedSPF, spf)
+			}
+		case "dkim-signature":
+			if tags, err := ParseTagList(h.Value); err == nil {
+				r.DKIMSignatures = append(r.DKIMSignatures, DKIMSignature{
+					Domain:    strings.ToLower(tags["d"]),
+					Selector:  tags["s"],
+					Algorithm: tags["a"],
+					Headers:   splitHeaderList(tags["h"]),
+				})
+			}
+		}
+	}
+	r.ARCSets = collectARCSets(headers)
+
+	for i := range r.DKIMSignatures {
+		r.DKIMSignatures[i].Aligned = Aligned(r.DKIMSignatures[i].Domain, r.FromDomain)
+	}
+
+	r.SPF = Verdict{Result: "none"}
+	r.DMARC = Verdict{Result: "none", Domain: r.FromDomain}
+	r.ARC = Verdict{Result: "none"}
+
+	var top *AuthResults
+	for _, ar := range r.AuthenticationResults {
+		if strings.EqualFold(ar.AuthServID, authServID) {
+			top = ar
+			break
+		}
+	}
+
+	if top != nil {
+		r.AuthServID = top.AuthServID
+		r.Verified = true
+		for _, mr := range top.Results {
+			switch mr.Method {
+			case "spf":
+				domain := addressDomain(mr.Properties["smtp.mailfrom"])
+				if domain == "" {
+					domain = strings.ToLower(mr.Properties["smtp.helo"])
+				}
+				r.SPF = Verdict{Result: mr.Result, Domain: domain, Aligned: Aligned(domain, r.FromDomain), Comment: mr.Comment}
+			case "dkim":
+				domain := strings.ToLower(mr.Properties["header.d"])
+				if domain == "" {
+					domain = addressDomain(mr.Properties["header.i"])
+				}
+				r.DKIM = append(r.DKIM, Verdict{
+					Result:   mr.Result,
+					Domain:   domain,
+					Selector: mr.Properties["header.s"],
+					Aligned:  Aligned(domain, r.FromDomain),
+					Comment:  mr.Comment,
+				})
+			case "dmarc":
+				domain := strings.ToLower(mr.Properties["header.from"])
+				if domain == "" {
+					domain = r.FromDomain
+				}
+				r.DMARC = Verdict{Result: mr.Result, Domain: domain, Aligned: mr.Result == "pass", Comment: mr.Comment}
+			case "arc":
+				r.ARC = Verdict{Result: mr.Result, Comment: mr.Comment}
+			}
+		}
+	}
+
+	// Fall back to Received-SPF when the receiver recorded no SPF result.
+	// Without a trusted Authentication-Results header there is no telling
+	// whether the receiver or the sender added it, so it is not used.
+	if r.Verified && r.SPF.Result == "none" && len(r.ReceivedSPF) > 0 {
+		spf := r.ReceivedSPF[0]
+		domain := addressDomain(spf.Fields["envelope-from"])
+		if domain == "" {
+			domain = strings.ToLower(spf.Fields["helo"])
+		}
+		r.SPF = Verdict{Result: spf.Result, Domain: domain, Aligned: Aligned(domain, r.FromDomain), Comment: spf.Comment}
+	}
+
+	// Without an arc= result, report the chain validation of the newest seal
+	if len(r.ARCSets) > 0 {
+		newest := r.ARCSets[len(r.ARCSets)-1]
+		r.ARC.Domain = newest.SealDomain
+		if r.ARC.Result == "none" && newest.ChainValidation != "" {
+			r.ARC.Result = "cv=" + newest.ChainValidation
+		}
+	}
+
+	return r
+}
+
+// collectARCSets groups ARC-Seal, ARC-Message-Signature and
+// ARC-Authentication-Results headers by instance number
+func collectARCSets(headers []Header) []ARCSet {
+	sets := make(map[int]*ARCSet)
+	get := func(i int) *ARCSet {
+		if sets[i] == nil {
+			sets[i] = &ARCSet{Instance: i}
+		}
+		return sets[i]
+	}
+
+	for _, h := range headers {
+		switch strings.ToLower(h.Name) {
+		case "arc-seal":
+			tags, err := ParseTagList(h.Value)
+			if err != nil {
+				continue
+			}
+			if i, err := strconv.Atoi(tags["i"]); err == nil {
+				set := get(i)
+				set.ChainValidation = strings.ToLower(tags["cv"])
+				set.SealDomain = strings.ToLower(tags["d"])
+			}
+		case "arc-message-signature":
+			tags, err := ParseTagList(h.Value)
+			if err != nil {
+				continue
+			}
+			if i, err := strconv.Atoi(tags["i"]); err == nil {
+				get(i).SignatureDomain = strings.ToLower(tags["d"])
+			}
+		case "arc-authentication-results":
+			// Value is "i=N; authserv-id; results..."
+			instance, rest, ok := strings.Cut(h.Value, ";")
+			if !ok {
+				continue
+			}
+			key, num, _ := strings.Cut(strings.TrimSpace(instance), "=")
+			i, err := strconv.Atoi(strings.TrimSpace(num))
+			if strings.TrimSpace(key) != "i" || err != nil {
+				continue
+			}
+			if ar, err := ParseAuthResults(rest); err == nil {
+				get(i).AuthResults = ar
+			}
+		}
+	}
+
+	result := make([]ARCSet, 0, len(sets))
+	for _, set := range sets {
+		result = append(result, *set)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Instance < result[j].Instance })
+	return result
+}
+
+// Aligned reports whether domain is in relaxed alignment with fromDomain,
+// i.e. both share the same organizational domain (RFC 7489 section 3.1)
+func Aligned(domain, fromDomain string) bool {
+	if domain == "" || fromDomain == "" {
+		return false
+	}
+	return OrganizationalDomain(domain) == OrganizationalDomain(fromDomain)
+}
+
+// secondLevelSuffixes are common second-level labels under country-code TLDs
+// that act as public suffixes (e.g. co.uk, com.au)
+var secondLevelSuffixes = map[string]bool{
+	"co": true, "com": true, "net": true, "org": true, "gov": true,
+	"edu": true, "ac": true, "or": true, "ne": true, "go": true,
+}
+
+// OrganizationalDomain approximates the registrable domain of a host name.
+// It does not consult the Public Suffix List; two-letter country TLDs with a
+// common second-level label (co.uk, com.au, ...) are handled heuristically.
+func OrganizationalDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	labels := strings.Split(domain, ".")
+	if len(labels) <= 2 {
+		return domain
+	}
+	n := 2
+	tld, sld := labels[len(labels)-1], labels[len(labels)-2]
+	if len(tld) == 2 && secondLevelSuffixes[sld] {
+		n = 3
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// addressDomain returns the lowercased domain of an address or address header
+func addressDomain(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if addr, err := mail.ParseAddress(value); err == nil {
+		value = addr.Address
+	}
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(value[at+1:], "<> "))
+}
+
+// splitHeaderList splits a DKIM h= tag into header names
+func splitHeaderList(h string) []string {
+	if h == "" {
+		return nil
+	}
+	names := strings.Split(h, ":")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
@@ -0,0 +1,138 @@
+package mailauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Run("builds verdict from the receiver's Authentication-Results", func(t *testing.T) {
+		headers := []Header{
+			{Name: "ARC-Seal", Value: "i=1; a=rsa-sha256; t=1700000000; cv=none; d=google.com; s=arc-20160816; b=xyz"},
+			{Name: "ARC-Message-Signature", Value: "i=1; a=rsa-sha256; d=google.com; s=arc-20160816; h=from:to; bh=abc; b=xyz"},
+			{Name: "ARC-Authentication-Results", Value: "i=1; mx.google.com; dkim=pass header.i=@news.example.com"},
+			{Name: "Authentication-Results", Value: "mx.google.com; dkim=pass header.i=@news.example.com header.s=s1; dkim=fail header.d=esp.net; spf=pass smtp.mailfrom=bounce@esp.net; dmarc=pass (p=NONE) header.from=example.com"},
+			{Name: "Received-SPF", Value: "pass client-ip=192.0.2.1; envelope-from=bounce@esp.net;"},
+			{Name: "Authentication-Results", Value: "spoofed.example; spf=pass smtp.mailfrom=example.com"},
+			{Name: "DKIM-Signature", Value: "v=1; a=rsa-sha256; d=news.example.com; s=s1; h=From:To:Subject; bh=abc; b=def"},
+			{Name: "DKIM-Signature", Value: "v=1; a=ed25519-sha256; d=esp.net; s=k1; h=From; bh=abc; b=def"},
+			{Name: "From", Value: `"Example News" <news@example.com>`},
+		}
+
+		r := Analyze(headers, DefaultAuthServID)
+
+		assert.Equal(t, "example.com", r.FromDomain)
+		assert.True(t, r.Verified)
+		assert.Equal(t, "mx.google.com", r.AuthServID)
+
+		assert.Equal(t, "pass", r.SPF.Result)
+		assert.Equal(t, "esp.net", r.SPF.Domain)
+		assert.False(t, r.SPF.Aligned)
+
+		require.Len(t, r.DKIM, 2)
+		assert.Equal(t, "news.example.com", r.DKIM[0].Domain)
+		assert.True(t, r.DKIM[0].Aligned)
+		assert.Equal(t, "fail", r.DKIM[1].Result)
+		assert.False(t, r.DKIM[1].Aligned)
+
+		assert.Equal(t, "pass", r.DMARC.Result)
+		assert.Equal(t, "example.com", r.DMARC.Domain)
+		assert.Equal(t, "p=NONE", r.DMARC.Comment)
+
+		assert.Equal(t, "cv=none", r.ARC.Result)
+		assert.Equal(t, "google.com", r.ARC.Domain)
+
+		require.Len(t, r.AuthenticationResults, 2)
+		require.Len(t, r.DKIMSignatures, 2)
+		assert.Equal(t, []string{"From", "To", "Subject"}, r.DKIMSignatures[0].Headers)
+		assert.True(t, r.DKIMSignatures[0].Aligned)
+		assert.False(t, r.DKIMSignatures[1].Aligned)
+
+		require.Len(t, r.ARCSets, 1)
+		assert.Equal(t, 1, r.ARCSets[0].Instance)
+		assert.Equal(t, "google.com", r.ARCSets[0].SignatureDomain)
+		require.NotNil(t, r.ARCSets[0].AuthResults)
+		assert.Equal(t, "mx.google.com", r.ARCSets[0].AuthResults.AuthServID)
+	})
+
+	t.Run("falls back to Received-SPF", func(t *testing.T) {
+		r := Analyze([]Header{
+			{Name: "Authentication-Results", Value: "mx.google.com; dkim=none"},
+			{Name: "From", Value: "a@example.com"},
+			{Name: "Received-SPF", Value: "fail client-ip=192.0.2.1; envelope-from=a@mail.example.com;"},
+		}, DefaultAuthServID)
+
+		assert.Equal(t, "fail", r.SPF.Result)
+		assert.Equal(t, "mail.example.com", r.SPF.Domain)
+		assert.True(t, r.SPF.Aligned)
+		assert.Equal(t, "none", r.DMARC.Result)
+		assert.True(t, r.Verified)
+	})
+
+	t.Run("ignores Received-SPF without a trusted receiver", func(t *testing.T) {
+		r := Analyze([]Header{
+			{Name: "From", Value: "a@example.com"},
+			{Name: "Received-SPF", Value: "pass client-ip=192.0.2.1; envelope-from=a@example.com;"},
+		}, DefaultAuthServID)
+
+		assert.False(t, r.Verified)
+		assert.Equal(t, "none", r.SPF.Result)
+		require.Len(t, r.ReceivedSPF, 1, "the header is still reported")
+	})
+
+	t.Run("ignores Authentication-Results from other services", func(t *testing.T) {
+		r := Analyze([]Header{
+			{Name: "Authentication-Results", Value: "spoofed.example; spf=pass smtp.mailfrom=example.com; dkim=pass header.d=example.com; dmarc=pass header.from=example.com"},
+			{Name: "From", Value: "a@example.com"},
+		}, DefaultAuthServID)
+
+		assert.False(t, r.Verified)
+		assert.Empty(t, r.AuthServID)
+		assert.Equal(t, "none", r.SPF.Result)
+		assert.Empty(t, r.DKIM)
+		assert.Equal(t, "none", r.DMARC.Result)
+		require.Len(t, r.AuthenticationResults, 1)
+	})
+
+	t.Run("trusts a configured authserv-id", func(t *testing.T) {
+		r := Analyze([]Header{
+			{Name: "Authentication-Results", Value: "MX.Example.COM; dmarc=fail header.from=example.com"},
+			{Name: "From", Value: "a@example.com"},
+		}, "mx.example.com")
+
+		assert.True(t, r.Verified)
+		assert.Equal(t, "fail", r.DMARC.Result)
+	})
+
+	t.Run("no authentication headers", func(t *testing.T) {
+		r := Analyze(nil, DefaultAuthServID)
+		assert.Equal(t, "none", r.SPF.Result)
+		assert.Equal(t, "none", r.ARC.Result)
+		assert.NotNil(t, r.DKIM)
+	})
+}
+
+func TestOrganizationalDomain(t *testing.T) {
+	tests := map[string]string{
+		"example.com":           "example.com",
+		"mail.news.example.com": "example.com",
+		"Example.COM.":          "example.com",
+		"shop.example.co.uk":    "example.co.uk",
+		"example.co.uk":         "example.co.uk",
+		"a.b.example.com.au":    "example.com.au",
+		"localhost":             "localhost",
+		"sub.example.io":        "example.io",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, OrganizationalDomain(input), input)
+	}
+}
+
+func TestAligned(t *testing.T) {
+	assert.True(t, Aligned("mail.example.com", "example.com"))
+	assert.True(t, Aligned("example.com", "news.example.com"))
+	assert.False(t, Aligned("example.net", "example.com"))
+	assert.False(t, Aligned("", "example.com"))
+}
@@ -0,0 +1,106 @@
+package mailauth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ErrKeyNotFound indicates that no key record exists for a selector
+var ErrKeyNotFound = errors.New("key record not found")
+
+// KeyResolver looks up the TXT records published at a DKIM key record name
+// such as "selector._domainkey.example.com"
+type KeyResolver interface {
+	LookupTXT(name string) ([]string, error)
+}
+
+// DNSResolver resolves DKIM keys using the system DNS resolver
+type DNSResolver struct{}
+
+// LookupTXT implements KeyResolver
+func (DNSResolver) LookupTXT(name string) ([]string, error) {
+	records, err := net.LookupTXT(name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, ErrKeyNotFound
+	}
+	return records, err
+}
+
+// StaticResolver resolves DKIM keys from an in-memory map of record name to
+// TXT record, for tests and offline verification
+type StaticResolver map[string]string
+
+// LookupTXT implements KeyResolver
+func (r StaticResolver) LookupTXT(name string) ([]string, error) {
+	record, ok := r[strings.ToLower(strings.TrimSuffix(name, "."))]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return []string{record}, nil
+}
+
+// LoadKeyFile reads DKIM key records for offline verification. Each line
+// holds a record name followed by its TXT value, in the style of a zone file:
+//
+//	sel._domainkey.example.com "v=DKIM1; k=rsa; p=MIIBIjANBg..."
+//
+// Blank lines and lines starting with '#' are ignored. A value split into
+// several quoted strings is joined, as DNS does for long TXT records.
+func LoadKeyFile(path string) (StaticResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %w", err)
+	}
+	defer f.Close()
+
+	resolver := make(StaticResolver)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sep := strings.IndexAny(line, " \t")
+		if sep < 0 {
+			return nil, fmt.Errorf("key file line %d: expected name and record", lineNum)
+		}
+		name, value := line[:sep], line[sep+1:]
+
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		resolver[name] = joinTXTStrings(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	return resolver, nil
+}
+
+// joinTXTStrings concatenates the quoted strings of a TXT value. Unquoted
+// values are returned as-is.
+func joinTXTStrings(value string) string {
+	if !strings.HasPrefix(value, `"`) {
+		return value
+	}
+	var b strings.Builder
+	inQuote := false
+	for _, r := range value {
+		if r == '"' {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
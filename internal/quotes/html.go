@@ -0,0 +1,135 @@
+package quotes
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// quoteClasses mark elements that hold quoted history or signatures in the
+// HTML produced by common mail clients
+var quoteClasses = []string{
+	"gmail_quote",     // Gmail quoted reply, including its attribution
+	"gmail_signature", // Gmail signature
+	"yahoo_quoted",    // Yahoo Mail
+	"moz-cite-prefix", // Thunderbird attribution
+	"moz-signature",   // Thunderbird signature
+}
+
+// historyIDs mark the element where Outlook starts the quoted history; it and
+// everything after it are removed
+var historyIDs = []string{"divRplyFwdMsg", "appendonsend", "stopSpelling"}
+
+// StripHTML removes quoted replies and signatures from an HTML body. It
+// returns the new HTML and, separately, the HTML that was removed.
+func StripHTML(src string) (string, string) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return src, ""
+	}
+
+	var removed []*html.Node
+	var walk func(*html.Node) bool
+	// walk returns true once the rest of the document has been removed
+	walk = func(n *html.Node) bool {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			switch {
+			case c.Type != html.ElementNode:
+			case isHistoryStart(c):
+				removed = append(removed, truncateFrom(c)...)
+				return true
+			case isQuoteElement(c):
+				n.RemoveChild(c)
+				removed = append(removed, c)
+			default:
+				if walk(c) {
+					return true
+				}
+			}
+			c = next
+		}
+		return false
+	}
+	walk(doc)
+
+	if len(removed) == 0 {
+		return src, ""
+	}
+
+	var body, quoted bytes.Buffer
+	if err := html.Render(&body, doc); err != nil {
+		return src, ""
+	}
+	for _, n := range removed {
+		if err := html.Render(&quoted, n); err != nil {
+			return src, ""
+		}
+	}
+	return body.String(), quoted.String()
+}
+
+func isQuoteElement(n *html.Node) bool {
+	if n.DataAtom == atom.Blockquote && strings.EqualFold(attr(n, "type"), "cite") {
+		return true
+	}
+	if attr(n, "data-smartmail") == "gmail_signature" {
+		return true
+	}
+	classes := strings.Fields(attr(n, "class"))
+	for _, want := range quoteClasses {
+		for _, class := range classes {
+			if class == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isHistoryStart(n *html.Node) bool {
+	id := attr(n, "id")
+	for _, want := range historyIDs {
+		if id == want {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateFrom removes n, its following siblings and the following siblings
+// of each of its ancestors up to <body>, returning the removed nodes in
+// document order
+func truncateFrom(n *html.Node) []*html.Node {
+	var removed []*html.Node
+	for n != nil {
+		parent := n.Parent
+		for c := n; c != nil; {
+			next := c.NextSibling
+			parent.RemoveChild(c)
+			removed = append(removed, c)
+			c = next
+		}
+
+		// Move on to the siblings following the nearest ancestor that has any
+		n = nil
+		for p := parent; p != nil && p.DataAtom != atom.Body && p.Type != html.DocumentNode; p = p.Parent {
+			if p.NextSibling != nil {
+				n = p.NextSibling
+				break
+			}
+		}
+	}
+	return removed
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
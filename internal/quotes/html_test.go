@@ -0,0 +1,49 @@
+package quotes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		body    string
+		removed string
+	}{
+		{
+			name:    "gmail quote and signature",
+			html:    `<div dir="ltr">Sounds good.<div class="gmail_signature" data-smartmail="gmail_signature">Bob</div></div><br><div class="gmail_quote"><div class="gmail_attr">On Mon, Alice wrote:</div><blockquote class="gmail_quote">Lunch?</blockquote></div>`,
+			body:    `<html><head></head><body><div dir="ltr">Sounds good.</div><br/></body></html>`,
+			removed: `<div class="gmail_signature" data-smartmail="gmail_signature">Bob</div><div class="gmail_quote"><div class="gmail_attr">On Mon, Alice wrote:</div><blockquote class="gmail_quote">Lunch?</blockquote></div>`,
+		},
+		{
+			name:    "apple mail cite",
+			html:    `<p>Yes</p><blockquote type="cite"><p>Lunch?</p></blockquote>`,
+			body:    `<html><head></head><body><p>Yes</p></body></html>`,
+			removed: `<blockquote type="cite"><p>Lunch?</p></blockquote>`,
+		},
+		{
+			name:    "outlook history",
+			html:    `<div><p>Approved.</p><div id="appendonsend"></div><hr><div id="divRplyFwdMsg">From: Alice</div><div>Please approve.</div></div><p>Disclaimer</p>`,
+			body:    `<html><head></head><body><div><p>Approved.</p></div></body></html>`,
+			removed: `<div id="appendonsend"></div><hr/><div id="divRplyFwdMsg">From: Alice</div><div>Please approve.</div><p>Disclaimer</p>`,
+		},
+		{
+			name:    "nothing to strip",
+			html:    `<p>Hello</p>`,
+			body:    `<p>Hello</p>`,
+			removed: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, removed := StripHTML(tt.html)
+			assert.Equal(t, tt.body, body)
+			assert.Equal(t, tt.removed, removed)
+		})
+	}
+}
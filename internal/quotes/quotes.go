@@ -0,0 +1,168 @@
+// Package quotes removes quoted reply history and signatures from message bodies.
+package quotes
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// attribution matches reply headers such as "On Mon, Jan 1, 2024 at 9:00 AM Alice <a@x> wrote:"
+	// in the languages most mail clients ship with
+	attribution = regexp.MustCompile(`(?i)^(on|le|am|el|il|op|em)\b.*\b(wrote|a écrit|schrieb|escribió|ha scritto|schreef|escreveu)\s*:\s*$`)
+
+	// originalMessage matches Outlook's and Lotus Notes' plain-text separators
+	originalMessage = regexp.MustCompile(`(?i)^-{2,}\s*original message\s*-{2,}$`)
+	underscoreRule  = regexp.MustCompile(`^_{10,}$`)
+	outlookFrom     = regexp.MustCompile(`(?i)^\*?from:\*?\s`)
+	outlookSent     = regexp.MustCompile(`(?i)^\*?(sent|date):\*?\s`)
+	outlookTo       = regexp.MustCompile(`(?i)^\*?(to|subject):\*?\s`)
+
+	// mobileSignature matches the one-line signatures added by mobile mail apps
+	mobileSignature = regexp.MustCompile(`(?i)^(sent from my \w+|sent from (mail|outlook) for \w+|get outlook for \w+)\b.*$`)
+)
+
+// Strip removes quoted replies and signatures from a plain text body. It
+// returns the new text and, separately, everything that was removed.
+//
+// Quoted history is recognized by reply attributions ("On ... wrote:"),
+// Outlook separators and header blocks, and lines starting with '>'. When
+// the text after an attribution is all quoted (a top-posted reply) the rest
+// of the body is dropped; interleaved replies keep their unquoted answers.
+// Signatures are recognized by the "-- " delimiter and mobile app taglines.
+func Strip(text string) (string, string) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var removed []string
+
+	// Everything from a reply header that starts the quoted history is removed
+	cut := len(lines)
+	for i := range lines {
+		if n := historyStart(lines, i); n >= 0 {
+			cut = n
+			break
+		}
+	}
+	if cut < len(lines) {
+		removed = append(removed, strings.Join(lines[cut:], "\n"))
+		lines = lines[:cut]
+	}
+
+	// The signature runs from the last delimiter to the end of what is left
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimRight(lines[i], " \t") == "--" {
+			removed = append([]string{strings.Join(lines[i:], "\n")}, removed...)
+			lines = lines[:i]
+			break
+		}
+	}
+	if last := lastNonBlank(lines); last >= 0 && mobileSignature.MatchString(strings.TrimSpace(lines[last])) {
+		removed = append([]string{lines[last]}, removed...)
+		lines = lines[:last]
+	}
+
+	// Remaining quote blocks belong to interleaved replies
+	var kept, quoted []string
+	for i, line := range lines {
+		if isQuoted(line) || (attribution.MatchString(strings.TrimSpace(line)) && nextNonBlankQuoted(lines, i+1)) {
+			quoted = append(quoted, line)
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(quoted) > 0 {
+		removed = append([]string{strings.Join(quoted, "\n")}, removed...)
+	}
+
+	return tidy(strings.Join(kept, "\n")), tidy(strings.Join(removed, "\n"))
+}
+
+// historyStart reports the line where the quoted history starts if line i
+// is a reply header that introduces it, or -1
+func historyStart(lines []string, i int) int {
+	line := strings.TrimSpace(lines[i])
+
+	if originalMessage.MatchString(line) {
+		return i
+	}
+
+	if outlookFrom.MatchString(line) && isOutlookHeader(lines[i:]) {
+		if i > 0 && underscoreRule.MatchString(strings.TrimSpace(lines[i-1])) {
+			return i - 1
+		}
+		return i
+	}
+
+	// Attributions are often wrapped onto a second line by the client
+	end := i
+	if !attribution.MatchString(line) {
+		if i+1 >= len(lines) || !strings.HasPrefix(strings.ToLower(line), "on ") {
+			return -1
+		}
+		if !attribution.MatchString(line + " " + strings.TrimSpace(lines[i+1])) {
+			return -1
+		}
+		end = i + 1
+	}
+
+	// Only a top-posted reply drops everything below the attribution
+	for _, rest := range lines[end+1:] {
+		if strings.TrimSpace(rest) != "" && !isQuoted(rest) {
+			return -1
+		}
+	}
+	return i
+}
+
+// isOutlookHeader reports whether lines start with an Outlook style header
+// block: a From: line followed by Sent: or Date: and To: or Subject: lines
+func isOutlookHeader(lines []string) bool {
+	var sent, to bool
+	for _, line := range lines[1:min(len(lines), 6)] {
+		line = strings.TrimSpace(line)
+		sent = sent || outlookSent.MatchString(line)
+		to = to || outlookTo.MatchString(line)
+	}
+	return sent && to
+}
+
+func isQuoted(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), ">")
+}
+
+func nextNonBlankQuoted(lines []string, from int) bool {
+	for _, line := range lines[from:] {
+		if strings.TrimSpace(line) != "" {
+			return isQuoted(line)
+		}
+	}
+	return false
+}
+
+func lastNonBlank(lines []string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// tidy trims surrounding blank lines and collapses runs of blank lines
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	var out []string
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = true
+			continue
+		}
+		if blank && len(out) > 0 {
+			out = append(out, "")
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
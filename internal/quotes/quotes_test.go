@@ -0,0 +1,85 @@
+package quotes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		body    string
+		removed string
+	}{
+		{
+			name:    "no quotes",
+			text:    "Hi Bob,\n\nSounds good.\n",
+			body:    "Hi Bob,\n\nSounds good.",
+			removed: "",
+		},
+		{
+			name:    "top-posted reply",
+			text:    "Sounds good.\n\nOn Mon, Jan 15, 2024 at 10:00 AM Alice <alice@example.com> wrote:\n> Shall we meet?\n>\n> Alice\n",
+			body:    "Sounds good.",
+			removed: "On Mon, Jan 15, 2024 at 10:00 AM Alice <alice@example.com> wrote:\n> Shall we meet?\n>\n> Alice",
+		},
+		{
+			name:    "wrapped attribution",
+			text:    "Yes.\r\n\r\nOn Mon, Jan 15, 2024 at 10:00 AM Alice Example <\r\nalice@example.com> wrote:\r\n\r\n> Shall we meet?\r\n",
+			body:    "Yes.",
+			removed: "On Mon, Jan 15, 2024 at 10:00 AM Alice Example <\nalice@example.com> wrote:\n\n> Shall we meet?",
+		},
+		{
+			name:    "interleaved reply keeps answers",
+			text:    "On Mon, Alice wrote:\n> Question one?\nAnswer one.\n> Question two?\nAnswer two.",
+			body:    "Answer one.\nAnswer two.",
+			removed: "On Mon, Alice wrote:\n> Question one?\n> Question two?",
+		},
+		{
+			name:    "outlook original message",
+			text:    "Approved.\n\n-----Original Message-----\nFrom: Alice\nSent: Monday\nTo: Bob\nSubject: Budget\n\nPlease approve.",
+			body:    "Approved.",
+			removed: "-----Original Message-----\nFrom: Alice\nSent: Monday\nTo: Bob\nSubject: Budget\n\nPlease approve.",
+		},
+		{
+			name:    "outlook header block",
+			text:    "Thanks!\n\n________________________________\nFrom: Alice <alice@example.com>\nSent: Monday, January 15, 2024 10:00 AM\nTo: Bob\nSubject: Budget\n\nPlease approve.",
+			body:    "Thanks!",
+			removed: "________________________________\nFrom: Alice <alice@example.com>\nSent: Monday, January 15, 2024 10:00 AM\nTo: Bob\nSubject: Budget\n\nPlease approve.",
+		},
+		{
+			name:    "from line alone is kept",
+			text:    "From: the team\nWelcome aboard.",
+			body:    "From: the team\nWelcome aboard.",
+			removed: "",
+		},
+		{
+			name:    "signature delimiter",
+			text:    "See you then.\n\n-- \nBob Smith\nACME Corp\n\nOn Mon, Alice wrote:\n> Lunch?",
+			body:    "See you then.",
+			removed: "--\nBob Smith\nACME Corp\n\nOn Mon, Alice wrote:\n> Lunch?",
+		},
+		{
+			name:    "mobile signature",
+			text:    "On my way.\n\nSent from my iPhone\n",
+			body:    "On my way.",
+			removed: "Sent from my iPhone",
+		},
+		{
+			name:    "non-english attribution",
+			text:    "D'accord.\n\nLe lun. 15 janv. 2024 à 10:00, Alice <alice@example.com> a écrit :\n> On se voit ?",
+			body:    "D'accord.",
+			removed: "Le lun. 15 janv. 2024 à 10:00, Alice <alice@example.com> a écrit :\n> On se voit ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, removed := Strip(tt.text)
+			assert.Equal(t, tt.body, body)
+			assert.Equal(t, tt.removed, removed)
+		})
+	}
+}
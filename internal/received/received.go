@@ -0,0 +1,265 @@
+// Package received parses the Received header chain of a message into hops
+// and flags routing anomalies.
+package received
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LargeDelay is the per-hop delay above which a hop is flagged
+const LargeDelay = time.Hour
+
+// Hop is one relay step, parsed from a single Received header
+type Hop struct {
+	Index        int        `json:"index"`
+	From         string     `json:"from,omitempty"`
+	FromRDNS     string     `json:"fromRdns,omitempty"`
+	IP           string     `json:"ip,omitempty"`
+	By           string     `json:"by,omitempty"`
+	With         string     `json:"with,omitempty"`
+	ID           string     `json:"id,omitempty"`
+	For          string     `json:"for,omitempty"`
+	Timestamp    *time.Time `json:"timestamp,omitempty"`
+	DelaySeconds *float64   `json:"delaySeconds,omitempty"`
+	Anomalies    []string   `json:"anomalies,omitempty"`
+	Raw          string     `json:"raw"`
+}
+
+// Delay returns the time spent since the previous hop, if known
+func (h Hop) Delay() (time.Duration, bool) {
+	if h.DelaySeconds == nil {
+		return 0, false
+	}
+	return time.Duration(*h.DelaySeconds * float64(time.Second)), true
+}
+
+// Route is the ordered list of hops from the origin to the final recipient
+type Route struct {
+	Hops              []Hop    `json:"hops"`
+	TotalDelaySeconds float64  `json:"totalDelaySeconds"`
+	Anomalies         []string `json:"anomalies,omitempty"`
+}
+
+var (
+	bracketIP   = regexp.MustCompile(`\[(?:IPv6:)?([0-9A-Fa-f:.]+)\]`)
+	bareIP      = regexp.MustCompile(`\b(\d{1,3}(?:\.\d{1,3}){3})\b`)
+	trailingTZ  = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+	clauseWords = map[string]bool{"from": true, "by": true, "via": true, "with": true, "id": true, "for": true}
+)
+
+// Analyze builds a route from Received header values in header order
+// (newest first, as they appear in the message). Hops are returned in
+// delivery order starting at the origin.
+func Analyze(values []string) *Route {
+	route := &Route{Hops: make([]Hop, 0, len(values))}
+
+	for i := len(values) - 1; i >= 0; i-- {
+		hop := Parse(values[i])
+		hop.Index = len(route.Hops) + 1
+		route.Hops = append(route.Hops, hop)
+	}
+
+	var prev *time.Time
+	for i := range route.Hops {
+		hop := &route.Hops[i]
+
+		if hop.Timestamp == nil {
+			hop.Anomalies = append(hop.Anomalies, "missing or unparseable timestamp")
+		} else if prev != nil {
+			d := hop.Timestamp.Sub(*prev)
+			secs := d.Seconds()
+			hop.DelaySeconds = &secs
+			switch {
+			case d < 0:
+				hop.Anomalies = append(hop.Anomalies, fmt.Sprintf("timestamp is %s earlier than previous hop", -d))
+			case d > LargeDelay:
+				hop.Anomalies = append(hop.Anomalies, fmt.Sprintf("delay of %s", d))
+			}
+		}
+		if hop.Timestamp != nil {
+			prev = hop.Timestamp
+		}
+
+		if ip := net.ParseIP(hop.IP); ip != nil && isNonPublic(ip) {
+			hop.Anomalies = append(hop.Anomalies, "private or reserved IP "+hop.IP)
+		}
+
+		for _, a := range hop.Anomalies {
+			route.Anomalies = append(route.Anomalies, fmt.Sprintf("hop %d: %s", hop.Index, a))
+		}
+	}
+
+	var first, last *time.Time
+	for _, hop := range route.Hops {
+		if hop.Timestamp == nil {
+			continue
+		}
+		if first == nil {
+			first = hop.Timestamp
+		}
+		last = hop.Timestamp
+	}
+	if first != nil && last != nil {
+		route.TotalDelaySeconds = last.Sub(*first).Seconds()
+	}
+
+	return route
+}
+
+// Parse parses a single Received header value (RFC 5321 section 4.4)
+func Parse(value string) Hop {
+	hop := Hop{Raw: strings.Join(strings.Fields(value), " ")}
+
+	clauses := value
+	if semi := strings.LastIndex(value, ";"); semi >= 0 {
+		clauses = value[:semi]
+		if t, ok := parseTimestamp(value[semi+1:]); ok {
+			hop.Timestamp = &t
+		}
+	}
+
+	var current string
+	for _, tok := range tokenize(clauses) {
+		if tok.comment {
+			if current == "from" && hop.IP == "" {
+				hop.FromRDNS, hop.IP = parseFromComment(tok.text)
+			}
+			continue
+		}
+
+		if word := strings.ToLower(tok.text); clauseWords[word] {
+			current = word
+			continue
+		}
+
+		switch current {
+		case "from":
+			if hop.From == "" {
+				hop.From = tok.text
+			}
+		case "by":
+			if hop.By == "" {
+				hop.By = tok.text
+			}
+		case "with":
+			if hop.With == "" {
+				hop.With = tok.text
+			}
+		case "id":
+			if hop.ID == "" {
+				hop.ID = tok.text
+			}
+		case "for":
+			if hop.For == "" {
+				hop.For = strings.Trim(tok.text, "<>")
+			}
+		}
+	}
+
+	// Some relays put the address in the from clause itself
+	if hop.IP == "" {
+		if m := bracketIP.FindStringSubmatch(hop.From); m != nil && net.ParseIP(m[1]) != nil {
+			hop.IP = m[1]
+		}
+	}
+
+	return hop
+}
+
+// parseFromComment extracts the reverse DNS name and IP from the comment of
+// a from clause, e.g. "mail.example.com [192.0.2.1]"
+func parseFromComment(comment string) (string, string) {
+	var ip string
+	if m := bracketIP.FindStringSubmatch(comment); m != nil && net.ParseIP(m[1]) != nil {
+		ip = m[1]
+	} else if m := bareIP.FindStringSubmatch(comment); m != nil && net.ParseIP(m[1]) != nil {
+		ip = m[1]
+	}
+
+	var rdns string
+	for _, field := range strings.Fields(comment) {
+		if strings.HasPrefix(field, "[") || strings.Contains(field, "=") {
+			break
+		}
+		if strings.Contains(field, ".") && net.ParseIP(field) == nil {
+			rdns = strings.Trim(field, "()")
+			break
+		}
+	}
+
+	return rdns, ip
+}
+
+func parseTimestamp(s string) (time.Time, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if t, err := mail.ParseDate(s); err == nil {
+		return t, true
+	}
+	if t, err := mail.ParseDate(trailingTZ.ReplaceAllString(s, "")); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// isNonPublic reports whether ip is private, loopback, link-local or unspecified
+func isNonPublic(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsUnspecified() || isSharedAddressSpace(ip)
+}
+
+// isSharedAddressSpace reports whether ip is in the carrier-grade NAT range 100.64.0.0/10
+func isSharedAddressSpace(ip net.IP) bool {
+	ip4 := ip.To4()
+	return ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64
+}
+
+type token struct {
+	text    string
+	comment bool
+}
+
+// tokenize splits a Received header into words and parenthesized comments
+func tokenize(s string) []token {
+	var tokens []token
+	var b strings.Builder
+	depth := 0
+
+	flush := func(comment bool) {
+		text := strings.TrimSpace(b.String())
+		b.Reset()
+		if text != "" {
+			tokens = append(tokens, token{text: text, comment: comment})
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '(':
+			if depth == 0 {
+				flush(false)
+			} else {
+				b.WriteRune(r)
+			}
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+			if depth == 0 {
+				flush(true)
+			} else {
+				b.WriteRune(r)
+			}
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\r' || r == '\n'):
+			flush(false)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush(depth > 0)
+
+	return tokens
+}
@@ -0,0 +1,149 @@
+package received
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("typical relay", func(t *testing.T) {
+		hop := Parse("from mail.example.com (mail.example.com [203.0.113.5])\r\n" +
+			"        by mx.google.com with ESMTPS id abc123\r\n" +
+			"        for <bob@gmail.com>;\r\n" +
+			"        Mon, 15 Jan 2024 10:30:05 -0800 (PST)")
+
+		assert.Equal(t, "mail.example.com", hop.From)
+		assert.Equal(t, "mail.example.com", hop.FromRDNS)
+		assert.Equal(t, "203.0.113.5", hop.IP)
+		assert.Equal(t, "mx.google.com", hop.By)
+		assert.Equal(t, "ESMTPS", hop.With)
+		assert.Equal(t, "abc123", hop.ID)
+		assert.Equal(t, "bob@gmail.com", hop.For)
+		require.NotNil(t, hop.Timestamp)
+		assert.Equal(t, time.Date(2024, 1, 15, 18, 30, 5, 0, time.UTC), hop.Timestamp.UTC())
+		assert.NotContains(t, hop.Raw, "\n")
+	})
+
+	t.Run("ipv6 and nested comments", func(t *testing.T) {
+		hop := Parse("from [10.0.0.1] (helo (nested) [IPv6:2001:db8::1]) by relay.example.org " +
+			"(Postfix) with ESMTP; 15 Jan 2024 10:30:05 +0000")
+
+		assert.Equal(t, "[10.0.0.1]", hop.From)
+		assert.Equal(t, "2001:db8::1", hop.IP)
+		assert.Equal(t, "relay.example.org", hop.By)
+		assert.Equal(t, "ESMTP", hop.With)
+	})
+
+	t.Run("ip only in from clause", func(t *testing.T) {
+		hop := Parse("from [192.168.1.20] by smtp.example.com; Mon, 15 Jan 2024 10:30:05 +0000")
+		assert.Equal(t, "192.168.1.20", hop.IP)
+	})
+
+	t.Run("by only", func(t *testing.T) {
+		hop := Parse("by 2002:a05:6a10:1234 with SMTP id x12csp; Mon, 15 Jan 2024 10:30:05 -0800 (PST)")
+		assert.Empty(t, hop.From)
+		assert.Equal(t, "2002:a05:6a10:1234", hop.By)
+		assert.Equal(t, "SMTP", hop.With)
+		assert.NotNil(t, hop.Timestamp)
+	})
+
+	t.Run("bad timestamp", func(t *testing.T) {
+		hop := Parse("from a by b; sometime yesterday")
+		assert.Nil(t, hop.Timestamp)
+	})
+}
+
+func TestAnalyze(t *testing.T) {
+	// Header order: newest first
+	headers := []string{
+		"by 2002:a05::1 with SMTP id x; Mon, 15 Jan 2024 18:30:10 +0000",
+		"from mail.example.com (mail.example.com [203.0.113.5]) by mx.google.com with ESMTPS; Mon, 15 Jan 2024 18:30:05 +0000",
+		"from laptop (unknown [192.168.1.20]) by mail.example.com with ESMTPSA; Mon, 15 Jan 2024 18:30:00 +0000",
+	}
+
+	route := Analyze(headers)
+	require.Len(t, route.Hops, 3)
+
+	assert.Equal(t, 1, route.Hops[0].Index)
+	assert.Equal(t, "laptop", route.Hops[0].From)
+	assert.Nil(t, route.Hops[0].DelaySeconds)
+	assert.Equal(t, []string{"private or reserved IP 192.168.1.20"}, route.Hops[0].Anomalies)
+
+	d, ok := route.Hops[1].Delay()
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+	assert.Empty(t, route.Hops[1].Anomalies)
+
+	assert.Equal(t, 3, route.Hops[2].Index)
+	assert.Equal(t, 10.0, route.TotalDelaySeconds)
+	assert.Equal(t, []string{"hop 1: private or reserved IP 192.168.1.20"}, route.Anomalies)
+}
+
+func TestAnalyzeAnomalies(t *testing.T) {
+	t.Run("time going backwards", func(t *testing.T) {
+		route := Analyze([]string{
+			"by b.example.com; Mon, 15 Jan 2024 18:29:00 +0000",
+			"by a.example.com; Mon, 15 Jan 2024 18:30:00 +0000",
+		})
+		require.Len(t, route.Hops, 2)
+		assert.Equal(t, []string{"timestamp is 1m0s earlier than previous hop"}, route.Hops[1].Anomalies)
+	})
+
+	t.Run("large delay", func(t *testing.T) {
+		route := Analyze([]string{
+			"by b.example.com; Mon, 15 Jan 2024 20:30:00 +0000",
+			"by a.example.com; Mon, 15 Jan 2024 18:30:00 +0000",
+		})
+		assert.Equal(t, []string{"delay of 2h0m0s"}, route.Hops[1].Anomalies)
+	})
+
+	t.Run("missing timestamp is skipped for delays", func(t *testing.T) {
+		route := Analyze([]string{
+			"by c.example.com; Mon, 15 Jan 2024 18:30:03 +0000",
+			"by b.example.com",
+			"by a.example.com; Mon, 15 Jan 2024 18:30:00 +0000",
+		})
+		require.Len(t, route.Hops, 3)
+		assert.Equal(t, []string{"missing or unparseable timestamp"}, route.Hops[1].Anomalies)
+		d, ok := route.Hops[2].Delay()
+		assert.True(t, ok)
+		assert.Equal(t, 3*time.Second, d)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		route := Analyze(nil)
+		assert.Empty(t, route.Hops)
+		assert.Zero(t, route.TotalDelaySeconds)
+	})
+}
+
+func TestIsNonPublic(t *testing.T) {
+	tests := map[string]bool{
+		"10.1.2.3":     true,
+		"172.16.0.1":   true,
+		"192.168.0.1":  true,
+		"127.0.0.1":    true,
+		"169.254.1.1":  true,
+		"100.64.0.1":   true,
+		"fd00::1":      true,
+		"fe80::1":      true,
+		"203.0.113.5":  false,
+		"8.8.8.8":      false,
+		"100.128.0.1":  false,
+		"2607:f8b0::1": false,
+	}
+	for ip, expected := range tests {
+		assert.Equal(t, expected, isNonPublic(parseIP(t, ip)), ip)
+	}
+}
+
+func parseIP(t *testing.T, s string) net.IP {
+	t.Helper()
+	ip := net.ParseIP(s)
+	require.NotNil(t, ip, s)
+	return ip
+}
@@ -0,0 +1,191 @@
+// Package saved manages named Gmail search queries stored in the gmro
+// configuration directory.
+package saved
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// FileName is the name of the saved searches file within the config directory
+const FileName = "saved_searches.json"
+
+// gmailDateLayout is the date format understood by Gmail's after:/before: operators
+const gmailDateLayout = "2006/01/02"
+
+var (
+	// ErrNotFound indicates no saved search exists with the given name
+	ErrNotFound = errors.New("saved search not found")
+	// ErrExists indicates a saved search with the given name already exists
+	ErrExists = errors.New("saved search already exists")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+)
+
+// Search is a named Gmail query
+type Search struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Store holds saved searches backed by a JSON file
+type Store struct {
+	path     string
+	searches map[string]string
+}
+
+type storeFile struct {
+	Searches map[string]string `json:"searches"`
+}
+
+// Load reads saved searches from path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, searches: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved searches: %w", err)
+	}
+
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse saved searches: %w", err)
+	}
+	for name, query := range f.Searches {
+		s.searches[name] = query
+	}
+
+	return s, nil
+}
+
+// Save writes the store back to its file with owner-only permissions
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(storeFile{Searches: s.searches}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode saved searches: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write saved searches: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write saved searches: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores a query under name. Existing entries are only replaced when
+// overwrite is true.
+func (s *Store) Add(name, query string, overwrite bool) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid name %q: use letters, digits, '-' and '_'", name)
+	}
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query must not be empty")
+	}
+	if _, err := parseTemplate(query); err != nil {
+		return err
+	}
+	if _, ok := s.searches[name]; ok && !overwrite {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	}
+
+	s.searches[name] = query
+	return nil
+}
+
+// Remove deletes the saved search with the given name
+func (s *Store) Remove(name string) error {
+	if _, ok := s.searches[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(s.searches, name)
+	return nil
+}
+
+// Get returns the query template saved under name
+func (s *Store) Get(name string) (string, error) {
+	query, ok := s.searches[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return query, nil
+}
+
+// List returns all saved searches sorted by name
+func (s *Store) List() []Search {
+	searches := make([]Search, 0, len(s.searches))
+	for name, query := range s.searches {
+		searches = append(searches, Search{Name: name, Query: query})
+	}
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].Name < searches[j].Name
+	})
+	return searches
+}
+
+// Expand renders a query template such as "newer_than:{{.Since}}".
+// Built-in parameters Today and Yesterday are formatted for Gmail's
+// after:/before: operators; entries in params override them. Referencing
+// a parameter that was not supplied is an error.
+func Expand(query string, params map[string]string, now time.Time) (string, error) {
+	tmpl, err := parseTemplate(query)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]string{
+		"Today":     now.Format(gmailDateLayout),
+		"Yesterday": now.AddDate(0, 0, -1).Format(gmailDateLayout),
+	}
+	for k, v := range params {
+		data[k] = v
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to expand query: %w", err)
+	}
+	return b.String(), nil
+}
+
+// ParseParams converts "key=value" strings into a parameter map
+func ParseParams(pairs []string) (map[string]string, error) {
+	params := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid parameter %q: expected key=value", pair)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+func parseTemplate(query string) (*template.Template, error) {
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query template: %w", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,130 @@
+package saved
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file yields empty store", func(t *testing.T) {
+		s, err := Load(filepath.Join(t.TempDir(), FileName))
+		require.NoError(t, err)
+		assert.Empty(t, s.List())
+	})
+
+	t.Run("invalid JSON returns error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), FileName)
+		require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+		_, err := Load(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Add("invoices", "from:billing@ has:attachment", false))
+	require.NoError(t, s.Add("alerts", "label:alerts is:unread", false))
+	require.NoError(t, s.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Search{
+		{Name: "alerts", Query: "label:alerts is:unread"},
+		{Name: "invoices", Query: "from:billing@ has:attachment"},
+	}, loaded.List())
+}
+
+func TestStoreAdd(t *testing.T) {
+	t.Run("rejects duplicate without overwrite", func(t *testing.T) {
+		s := &Store{searches: map[string]string{"a": "x"}}
+		err := s.Add("a", "y", false)
+		assert.ErrorIs(t, err, ErrExists)
+
+		require.NoError(t, s.Add("a", "y", true))
+		q, err := s.Get("a")
+		require.NoError(t, err)
+		assert.Equal(t, "y", q)
+	})
+
+	t.Run("rejects invalid names", func(t *testing.T) {
+		s := &Store{searches: map[string]string{}}
+		for _, name := range []string{"", "@inv", "has space", "-lead"} {
+			assert.Error(t, s.Add(name, "q", false), "name %q", name)
+		}
+	})
+
+	t.Run("rejects empty query", func(t *testing.T) {
+		s := &Store{searches: map[string]string{}}
+		assert.Error(t, s.Add("a", "   ", false))
+	})
+
+	t.Run("rejects malformed template", func(t *testing.T) {
+		s := &Store{searches: map[string]string{}}
+		assert.Error(t, s.Add("a", "after:{{.Since", false))
+	})
+}
+
+func TestStoreRemove(t *testing.T) {
+	s := &Store{searches: map[string]string{"a": "x"}}
+	require.NoError(t, s.Remove("a"))
+	assert.ErrorIs(t, s.Remove("a"), ErrNotFound)
+
+	_, err := s.Get("a")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestExpand(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		params   map[string]string
+		expected string
+		wantErr  bool
+	}{
+		{"plain query unchanged", "from:billing@", nil, "from:billing@", false},
+		{"substitutes parameter", "after:{{.Since}}", map[string]string{"Since": "2024/01/01"}, "after:2024/01/01", false},
+		{"built-in today", "before:{{.Today}}", nil, "before:2024/03/01", false},
+		{"built-in yesterday", "after:{{.Yesterday}}", nil, "after:2024/02/29", false},
+		{"params override built-ins", "{{.Today}}", map[string]string{"Today": "x"}, "x", false},
+		{"missing parameter errors", "after:{{.Since}}", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Expand(tt.query, tt.params, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams([]string{"Since=2024/01/01", "Q=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Since": "2024/01/01", "Q": "a=b"}, params)
+
+	_, err = ParseParams([]string{"novalue"})
+	assert.Error(t, err)
+
+	_, err = ParseParams([]string{"=x"})
+	assert.Error(t, err)
+}